@@ -10,8 +10,20 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/ran/demo/backend-go/internal/config"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/embedding"
+	"github.com/ran/demo/backend-go/internal/infra/keywords"
+	"github.com/ran/demo/backend-go/internal/infra/llm"
+	"github.com/ran/demo/backend-go/internal/infra/llm/fallback"
+	"github.com/ran/demo/backend-go/internal/infra/llm/prompts"
+	"github.com/ran/demo/backend-go/internal/infra/mlnative"
+	"github.com/ran/demo/backend-go/internal/infra/mlservice"
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore"
+	"github.com/ran/demo/backend-go/internal/repository"
+	"github.com/ran/demo/backend-go/internal/server"
+	"github.com/ran/demo/backend-go/internal/service"
 )
 
 func main() {
@@ -21,8 +33,75 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Initialize the LLM provider chain
+	llmChain, err := llm.NewChain(cfg.LLM, cfg.LLMFallback)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider: %v", err)
+	}
+	chatHandler := server.NewChatHandler(llmChain)
+
+	// Initialize the ingestion pipeline
+	tok, err := tokenizer.New(cfg.Tokenizer)
+	if err != nil {
+		log.Fatalf("Failed to initialize tokenizer: %v", err)
+	}
+	embedder, err := newEmbedder(cfg, llmChain, tok)
+	if err != nil {
+		log.Fatalf("Failed to initialize embedding model: %v", err)
+	}
+	analyzer, err := newAnalyzer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize vector analysis: %v", err)
+	}
+	store, err := vectorstore.NewStore(cfg.VectorStore)
+	if err != nil {
+		log.Fatalf("Failed to initialize vector store: %v", err)
+	}
+	defer store.Close()
+	ingestion, err := service.NewIngestionService(llmChain, embedder, analyzer, store, service.IngestionConfig{
+		MaxTokensPerChunk:   cfg.Chunking.MaxTokens,
+		ChunksCollection:    cfg.VectorStore.Collections.Chunks,
+		SummariesCollection: cfg.VectorStore.Collections.Summaries,
+		Chunking:            service.ChunkingStrategy(cfg.Chunking.Strategy),
+		Semantic: service.SemanticChunking{
+			Percentile: cfg.Chunking.SemanticPercentile,
+			MinTokens:  cfg.Chunking.SemanticMinTokens,
+		},
+		Overlap: service.Overlap{
+			Tokens:    cfg.Chunking.OverlapTokens,
+			Sentences: cfg.Chunking.OverlapSentences,
+		},
+		Hierarchical:        cfg.Chunking.Hierarchical,
+		MaxTokensPerSection: cfg.Chunking.MaxSectionTokens,
+		Keywords:            keywords.NewExtractor(cfg.Keywords.MaxPerChunk),
+		TermStats:           repository.NewMemoryTermStatsRepository(),
+		MaxDocumentKeywords: cfg.Keywords.MaxPerDocument,
+		RefineKeywords:      cfg.Keywords.LLMRefine,
+		Summary: service.SummarizerConfig{
+			MaxTokensPerCall: cfg.LLM.MaxTokensPerCall,
+			PromptTokens:     tok.CountTokens(prompts.Summary("")),
+		},
+		Tokenizer:             tok,
+		MinClusterProbability: cfg.Analysis.MinClusterProbability,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize ingestion: %v", err)
+	}
+
+	// Initialize document storage; uploads are ingested in the background
+	documentRepo := repository.NewMemoryDocumentRepository()
+	uploader, err := service.NewFileUploader(cfg.Upload.StorageDir, cfg.Upload.MaxFileSize, documentRepo)
+	if err != nil {
+		log.Fatalf("Failed to initialize uploader: %v", err)
+	}
+	pipeline := service.NewDocumentPipeline(ingestion, uploader, documentRepo)
+	documentHandler := server.NewDocumentHandler(uploader, pipeline, server.UploadLimits{
+		MaxFileSize:    cfg.Upload.MaxFileSize,
+		MaxRequestSize: cfg.Upload.MaxRequestSize,
+	})
+
 	// Initialize Gin router
-	router := server.SetupRouter()
+	router := server.SetupRouter(documentHandler, chatHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -51,6 +130,61 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	// Stop background ingestion before the vector store is closed
+	documentHandler.Close()
 
 	log.Println("Server exiting")
 }
+
+// newEmbedder batches and throttles embedding requests to the provider limits in cfg, and caches
+// the vectors of the primary provider unless caching is disabled
+func newEmbedder(cfg *config.Config, model *fallback.Chain, tok ports.Tokenizer) (ports.EmbeddingModel, error) {
+	batching, err := embedding.NewBatchingModel(model, embedding.Config{
+		MaxBatchSize:      cfg.Embedding.MaxBatchSize,
+		MaxBatchTokens:    cfg.Embedding.MaxBatchTokens,
+		Concurrency:       cfg.Embedding.Concurrency,
+		RequestsPerMinute: cfg.Embedding.RequestsPerMinute,
+		MaxRetries:        cfg.Embedding.MaxRetries,
+		Tokenizer:         tok,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache, err := embedding.NewCacheStore(cfg.Embedding)
+	if err != nil || cache == nil {
+		return batching, err
+	}
+	return embedding.NewCachedModel(batching, cfg.LLM.Provider+"/"+cfg.LLM.EmbeddingModel, cache, model.ServedByPrimary)
+}
+
+// newAnalyzer creates the vector analysis backend selected by cfg.Analysis.Backend
+func newAnalyzer(cfg *config.Config) (ports.VectorAnalysisService, error) {
+	native, err := mlnative.NewNativeAnalyzer(mlnative.Config{
+		Dimensions: cfg.ML.Dimensions,
+		KSelection: cfg.Analysis.KSelection,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Analysis.Backend == config.AnalysisNative {
+		return native, nil
+	}
+	ml, err := mlservice.NewMLServiceClient(mlservice.Config{
+		BaseURL:         cfg.ML.BaseURL,
+		Timeout:         cfg.ML.Timeout,
+		MaxRetries:      cfg.ML.MaxRetries,
+		MaxPayloadBytes: cfg.ML.MaxPayloadBytes,
+		Dimensions:      cfg.ML.Dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Analysis.Backend {
+	case config.AnalysisML:
+		return ml, nil
+	case config.AnalysisAuto:
+		return service.NewFallbackAnalyzer(ml, native, cfg.Analysis.SmallSpaceThreshold), nil
+	default:
+		return nil, fmt.Errorf("unknown analysis backend %q", cfg.Analysis.Backend)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
@@ -14,6 +16,13 @@ type Config struct {
 	GeminiAPI   GeminiConfig
 	VectorStore VectorStoreConfig
 	LLM         LLMConfig
+	LLMFallback LLMFallbackConfig
+	Upload      UploadConfig
+	Analysis    AnalysisConfig
+	Tokenizer   TokenizerConfig
+	Chunking    ChunkingConfig
+	Keywords    KeywordsConfig
+	Embedding   EmbeddingConfig
 }
 
 // ServerConfig holds configuration for the HTTP server
@@ -23,7 +32,11 @@ type ServerConfig struct {
 
 // MLServiceConfig holds configuration for the Python ML service
 type MLServiceConfig struct {
-	BaseURL string
+	BaseURL         string
+	Timeout         time.Duration
+	MaxRetries      int
+	MaxPayloadBytes int
+	Dimensions      int
 }
 
 // QdrantConfig holds configuration for Qdrant vector database
@@ -39,8 +52,11 @@ type GeminiConfig struct {
 	APIKey string
 }
 
-// VectorStoreConfig holds Qdrant-specific configuration
+// VectorStoreConfig holds vector store configuration.
+// Backend selects the implementation; Path enables on-disk persistence for the memory backend.
 type VectorStoreConfig struct {
+	Backend     string
+	Path        string
 	Endpoint    string
 	APIKey      string
 	Collections struct {
@@ -49,14 +65,130 @@ type VectorStoreConfig struct {
 	}
 }
 
-// LLMConfig holds Gemini-specific configuration
+// LLMConfig holds LLM provider configuration. Provider "openai" speaks the OpenAI-compatible
+// API, which vLLM and llama.cpp servers also serve, and "ollama" the same API of a local Ollama.
+// Provider "fake" answers offline and deterministically, for local development without an API key.
+// Name labels the provider in the fallback chain and its errors; the primary's defaults to
+// Provider, overridden by LLM_NAME.
 type LLMConfig struct {
+	Name             string
+	Provider         string
 	APIKey           string
+	BaseURL          string
+	GenerationModel  string
 	EmbeddingModel   string
 	EmbeddingDim     uint64
 	MaxTokensPerCall int
 }
 
+// UploadConfig holds limits and storage location for uploaded documents
+type UploadConfig struct {
+	StorageDir     string
+	MaxFileSize    int64
+	MaxRequestSize int64
+}
+
+// TokenizerConfig selects how tokens are counted for chunking and token budgets.
+// VocabPath points to a SentencePiece vocab file; when empty a character-based estimate is used.
+type TokenizerConfig struct {
+	VocabPath string
+}
+
+// ChunkingConfig controls how documents are cut into chunks.
+// At most one of OverlapTokens and OverlapSentences may be set. Strategy "semantic" cuts plain
+// text where adjacent sentences are least similar; SemanticPercentile and SemanticMinTokens tune it.
+// Hierarchical additionally indexes section and sentence chunks around the paragraph chunks;
+// sections over MaxSectionTokens are split.
+type ChunkingConfig struct {
+	Strategy           string
+	Hierarchical       bool
+	MaxTokens          int
+	MaxSectionTokens   int
+	OverlapTokens      int
+	OverlapSentences   int
+	SemanticPercentile float64
+	SemanticMinTokens  int
+}
+
+// Chunking strategies
+const (
+	ChunkingFixed    = "fixed"
+	ChunkingSemantic = "semantic"
+)
+
+// KeywordsConfig controls keyword extraction. Keywords are extracted locally; LLMRefine
+// additionally asks the LLM for each chunk's keywords, at one call per chunk. MaxPerDocument
+// caps the document's keywords, ranked across its chunks.
+type KeywordsConfig struct {
+	MaxPerChunk    int
+	MaxPerDocument int
+	LLMRefine      bool
+}
+
+// EmbeddingConfig holds the provider limits embedding requests are batched and throttled to,
+// where zero values select the decorator's defaults, and the embedding cache. CachePath is
+// the cache file of the "disk" backend.
+type EmbeddingConfig struct {
+	MaxBatchSize      int
+	MaxBatchTokens    int
+	Concurrency       int
+	RequestsPerMinute int
+	MaxRetries        int
+	CacheBackend      string
+	CachePath         string
+}
+
+// LLMFallbackConfig lists the providers tried, in order, when the primary LLM provider fails.
+// Their embedding dimension must match the primary's, or the chain is rejected at startup; the
+// n-th fallback (from 1) reads its name, models, dimension and base URL from LLM_FALLBACK_<n>_*
+// variables, defaulting to its provider's settings and the name "fallback<n>".
+// A provider's circuit breaker opens after FailureThreshold consecutive failures and lets a
+// trial call through once Cooldown has passed.
+type LLMFallbackConfig struct {
+	Providers        []LLMConfig
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// LLM providers
+const (
+	LLMProviderGemini = "gemini"
+	LLMProviderOpenAI = "openai"
+	LLMProviderOllama = "ollama"
+	LLMProviderFake   = "fake"
+)
+
+// Embedding cache backends
+const (
+	EmbeddingCacheNone   = "none"
+	EmbeddingCacheMemory = "memory"
+	EmbeddingCacheDisk   = "disk"
+)
+
+// AnalysisConfig selects how vectors are reduced and clustered.
+// Backend "auto" uses the ML service and falls back to the native Go implementation
+// on failure or for inputs smaller than SmallSpaceThreshold. MinClusterProbability > 0
+// enables soft clustering, where a chunk joins every cluster it reaches that probability in.
+type AnalysisConfig struct {
+	Backend               string
+	SmallSpaceThreshold   int
+	KSelection            string
+	MinClusterProbability float64
+}
+
+// Analysis backends
+const (
+	AnalysisML     = "ml"
+	AnalysisNative = "native"
+	AnalysisAuto   = "auto"
+)
+
+// Vector store backends
+const (
+	VectorStoreQdrant = "qdrant"
+	VectorStoreMemory = "memory"
+)
+
 // Default collection names
 const (
 	DefaultSummariesCollection = "doc_summaries"
@@ -71,7 +203,11 @@ func Load() (*Config, error) {
 			Port: 8080,
 		},
 		ML: MLServiceConfig{
-			BaseURL: "http://backend-py:5000",
+			BaseURL:         "http://backend-py:5000",
+			Timeout:         60 * time.Second,
+			MaxRetries:      3,
+			MaxPayloadBytes: 8 << 20,
+			Dimensions:      3,
 		},
 		Qdrant: QdrantConfig{
 			Host:   "qdrant",
@@ -93,6 +229,13 @@ func Load() (*Config, error) {
 	if mlURL := os.Getenv("ML_SERVICE_URL"); mlURL != "" {
 		cfg.ML.BaseURL = mlURL
 	}
+	if mlTimeout := os.Getenv("ML_SERVICE_TIMEOUT"); mlTimeout != "" {
+		timeout, err := time.ParseDuration(mlTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ML_SERVICE_TIMEOUT value: %v", err)
+		}
+		cfg.ML.Timeout = timeout
+	}
 
 	if qdrantHost := os.Getenv("QDRANT_HOST"); qdrantHost != "" {
 		cfg.Qdrant.Host = qdrantHost
@@ -111,23 +254,205 @@ func Load() (*Config, error) {
 
 	if geminiAPIKey := os.Getenv("GEMINI_API_KEY"); geminiAPIKey != "" {
 		cfg.GeminiAPI.APIKey = geminiAPIKey
-	} else {
-		// For demo purposes, we'll allow running without a Gemini API key
-		// but in production, this should be required
-		fmt.Println("Warning: GEMINI_API_KEY not set")
 	}
 
 	// Vector store config
+	cfg.VectorStore.Backend = getEnvOrDefault("VECTOR_STORE_BACKEND", VectorStoreQdrant)
+	cfg.VectorStore.Path = os.Getenv("VECTOR_STORE_PATH")
 	cfg.VectorStore.Endpoint = getEnvOrDefault("QDRANT_ENDPOINT", "http://localhost:6334")
 	cfg.VectorStore.APIKey = os.Getenv("QDRANT_API_KEY")
 	cfg.VectorStore.Collections.Summaries = DefaultSummariesCollection
 	cfg.VectorStore.Collections.Chunks = DefaultChunksCollection
 
 	// LLM config
-	cfg.LLM.APIKey = os.Getenv("GEMINI_API_KEY")
-	cfg.LLM.EmbeddingModel = getEnvOrDefault("GEMINI_EMBEDDING_MODEL", "models/embedding-001")
-	cfg.LLM.EmbeddingDim = 768 // Default dimension for Gemini embeddings
+	cfg.LLM.Provider = os.Getenv("LLM_PROVIDER")
+	if cfg.LLM.Provider == "" {
+		cfg.LLM.Provider = LLMProviderGemini
+		if cfg.GeminiAPI.APIKey == "" {
+			// For demo purposes, we'll allow running without a Gemini API key
+			// but in production, this should be required
+			fmt.Println("Warning: GEMINI_API_KEY not set, using the offline LLM provider")
+			cfg.LLM.Provider = LLMProviderFake
+		}
+	}
+	llmCfg, err := llmProviderConfig(cfg.LLM.Provider, cfg.GeminiAPI.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_PROVIDER value: %v", err)
+	}
+	cfg.LLM = llmCfg
+	cfg.LLM.Name = getEnvOrDefault("LLM_NAME", cfg.LLM.Provider)
+	if dim := os.Getenv("LLM_EMBEDDING_DIM"); dim != "" {
+		n, err := strconv.ParseUint(dim, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_EMBEDDING_DIM value: %v", err)
+		}
+		cfg.LLM.EmbeddingDim = n
+	}
 	cfg.LLM.MaxTokensPerCall = 1024
+	if maxTokens := os.Getenv("LLM_MAX_TOKENS_PER_CALL"); maxTokens != "" {
+		n, err := strconv.Atoi(maxTokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_MAX_TOKENS_PER_CALL value: %v", err)
+		}
+		cfg.LLM.MaxTokensPerCall = n
+	}
+	cfg.LLMFallback.FailureThreshold = 3
+	cfg.LLMFallback.Cooldown = 30 * time.Second
+	if fallbacks := os.Getenv("LLM_FALLBACK_PROVIDERS"); fallbacks != "" {
+		for i, provider := range strings.Split(fallbacks, ",") {
+			fallback, err := llmProviderConfig(strings.TrimSpace(provider), cfg.GeminiAPI.APIKey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid LLM_FALLBACK_PROVIDERS value: %v", err)
+			}
+			if fallback.Provider == LLMProviderFake {
+				// The offline embedder can match the primary's dimension and so back up its embeddings
+				fallback.EmbeddingDim = cfg.LLM.EmbeddingDim
+			}
+			if err := fallbackOverrides(&fallback, i+1); err != nil {
+				return nil, err
+			}
+			fallback.MaxTokensPerCall = cfg.LLM.MaxTokensPerCall
+			cfg.LLMFallback.Providers = append(cfg.LLMFallback.Providers, fallback)
+		}
+	}
+	if failures := os.Getenv("LLM_BREAKER_FAILURES"); failures != "" {
+		n, err := strconv.Atoi(failures)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_BREAKER_FAILURES value: %v", err)
+		}
+		cfg.LLMFallback.FailureThreshold = n
+	}
+	if cooldown := os.Getenv("LLM_BREAKER_COOLDOWN"); cooldown != "" {
+		d, err := time.ParseDuration(cooldown)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_BREAKER_COOLDOWN value: %v", err)
+		}
+		cfg.LLMFallback.Cooldown = d
+	}
+
+	// Upload config
+	cfg.Upload.StorageDir = getEnvOrDefault("UPLOAD_DIR", "./data/uploads")
+	cfg.Upload.MaxFileSize = 10 << 20
+	cfg.Upload.MaxRequestSize = 50 << 20
+	if maxFile := os.Getenv("UPLOAD_MAX_FILE_BYTES"); maxFile != "" {
+		size, err := strconv.ParseInt(maxFile, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPLOAD_MAX_FILE_BYTES value: %v", err)
+		}
+		cfg.Upload.MaxFileSize = size
+	}
+	if maxRequest := os.Getenv("UPLOAD_MAX_REQUEST_BYTES"); maxRequest != "" {
+		size, err := strconv.ParseInt(maxRequest, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPLOAD_MAX_REQUEST_BYTES value: %v", err)
+		}
+		cfg.Upload.MaxRequestSize = size
+	}
+
+	// Tokenizer config
+	cfg.Tokenizer.VocabPath = os.Getenv("TOKENIZER_VOCAB_PATH")
+
+	// Chunking config
+	cfg.Chunking.Strategy = getEnvOrDefault("CHUNK_STRATEGY", ChunkingFixed)
+	if cfg.Chunking.Strategy != ChunkingFixed && cfg.Chunking.Strategy != ChunkingSemantic {
+		return nil, fmt.Errorf("invalid CHUNK_STRATEGY value: %q", cfg.Chunking.Strategy)
+	}
+	if hierarchical := os.Getenv("CHUNK_HIERARCHICAL"); hierarchical != "" {
+		b, err := strconv.ParseBool(hierarchical)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CHUNK_HIERARCHICAL value: %v", err)
+		}
+		cfg.Chunking.Hierarchical = b
+	}
+	cfg.Chunking.MaxTokens = 256
+	cfg.Chunking.MaxSectionTokens = 1024
+	cfg.Chunking.SemanticPercentile = 10
+	if percentile := os.Getenv("CHUNK_SEMANTIC_PERCENTILE"); percentile != "" {
+		p, err := strconv.ParseFloat(percentile, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CHUNK_SEMANTIC_PERCENTILE value: %v", err)
+		}
+		cfg.Chunking.SemanticPercentile = p
+	}
+	for key, dst := range map[string]*int{
+		"CHUNK_MAX_TOKENS":          &cfg.Chunking.MaxTokens,
+		"CHUNK_MAX_SECTION_TOKENS":  &cfg.Chunking.MaxSectionTokens,
+		"CHUNK_OVERLAP_TOKENS":      &cfg.Chunking.OverlapTokens,
+		"CHUNK_OVERLAP_SENTENCES":   &cfg.Chunking.OverlapSentences,
+		"CHUNK_SEMANTIC_MIN_TOKENS": &cfg.Chunking.SemanticMinTokens,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value: %v", key, err)
+			}
+			*dst = n
+		}
+	}
+
+	// Keywords config
+	cfg.Keywords.MaxPerChunk = 10
+	if maxKeywords := os.Getenv("KEYWORDS_MAX_PER_CHUNK"); maxKeywords != "" {
+		n, err := strconv.Atoi(maxKeywords)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KEYWORDS_MAX_PER_CHUNK value: %v", err)
+		}
+		cfg.Keywords.MaxPerChunk = n
+	}
+	cfg.Keywords.MaxPerDocument = 20
+	if maxKeywords := os.Getenv("KEYWORDS_MAX_PER_DOCUMENT"); maxKeywords != "" {
+		n, err := strconv.Atoi(maxKeywords)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KEYWORDS_MAX_PER_DOCUMENT value: %v", err)
+		}
+		cfg.Keywords.MaxPerDocument = n
+	}
+	if refine := os.Getenv("KEYWORDS_LLM_REFINE"); refine != "" {
+		b, err := strconv.ParseBool(refine)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KEYWORDS_LLM_REFINE value: %v", err)
+		}
+		cfg.Keywords.LLMRefine = b
+	}
+
+	// Embedding config
+	cfg.Embedding.CacheBackend = getEnvOrDefault("EMBEDDING_CACHE", EmbeddingCacheMemory)
+	cfg.Embedding.CachePath = getEnvOrDefault("EMBEDDING_CACHE_PATH", "./data/embedding-cache.bin")
+	for key, dst := range map[string]*int{
+		"EMBEDDING_MAX_BATCH_SIZE":      &cfg.Embedding.MaxBatchSize,
+		"EMBEDDING_MAX_BATCH_TOKENS":    &cfg.Embedding.MaxBatchTokens,
+		"EMBEDDING_CONCURRENCY":         &cfg.Embedding.Concurrency,
+		"EMBEDDING_REQUESTS_PER_MINUTE": &cfg.Embedding.RequestsPerMinute,
+		"EMBEDDING_MAX_RETRIES":         &cfg.Embedding.MaxRetries,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value: %v", key, err)
+			}
+			*dst = n
+		}
+	}
+
+	// Analysis config
+	cfg.Analysis.Backend = getEnvOrDefault("ANALYSIS_BACKEND", AnalysisAuto)
+	cfg.Analysis.KSelection = getEnvOrDefault("ANALYSIS_K_SELECTION", "silhouette")
+	cfg.Analysis.SmallSpaceThreshold = 50
+	if threshold := os.Getenv("ANALYSIS_SMALL_SPACE_THRESHOLD"); threshold != "" {
+		n, err := strconv.Atoi(threshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ANALYSIS_SMALL_SPACE_THRESHOLD value: %v", err)
+		}
+		cfg.Analysis.SmallSpaceThreshold = n
+	}
+	cfg.Analysis.MinClusterProbability = 0.3
+	if minProb := os.Getenv("ANALYSIS_MIN_CLUSTER_PROBABILITY"); minProb != "" {
+		p, err := strconv.ParseFloat(minProb, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ANALYSIS_MIN_CLUSTER_PROBABILITY value: %v", err)
+		}
+		cfg.Analysis.MinClusterProbability = p
+	}
 
 	return cfg, nil
 }
@@ -138,3 +463,47 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// fallbackOverrides applies the LLM_FALLBACK_<n>_* variables of the n-th fallback provider
+func fallbackOverrides(cfg *LLMConfig, n int) error {
+	prefix := fmt.Sprintf("LLM_FALLBACK_%d_", n)
+	cfg.Name = getEnvOrDefault(prefix+"NAME", fmt.Sprintf("fallback%d", n))
+	cfg.BaseURL = getEnvOrDefault(prefix+"BASE_URL", cfg.BaseURL)
+	cfg.GenerationModel = getEnvOrDefault(prefix+"MODEL", cfg.GenerationModel)
+	cfg.EmbeddingModel = getEnvOrDefault(prefix+"EMBEDDING_MODEL", cfg.EmbeddingModel)
+	if dim := os.Getenv(prefix + "EMBEDDING_DIM"); dim != "" {
+		n, err := strconv.ParseUint(dim, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %sEMBEDDING_DIM value: %v", prefix, err)
+		}
+		cfg.EmbeddingDim = n
+	}
+	return nil
+}
+
+// llmProviderConfig returns the settings of provider, read from its provider-specific variables
+func llmProviderConfig(provider, geminiAPIKey string) (LLMConfig, error) {
+	cfg := LLMConfig{Provider: provider}
+	switch provider {
+	case LLMProviderGemini, LLMProviderFake:
+		cfg.APIKey = geminiAPIKey
+		cfg.BaseURL = getEnvOrDefault("GEMINI_BASE_URL", "https://generativelanguage.googleapis.com")
+		cfg.GenerationModel = getEnvOrDefault("GEMINI_MODEL", "models/gemini-1.5-flash")
+		cfg.EmbeddingModel = getEnvOrDefault("GEMINI_EMBEDDING_MODEL", "models/embedding-001")
+		cfg.EmbeddingDim = 768 // Default dimension for Gemini embeddings
+	case LLMProviderOpenAI:
+		cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+		cfg.BaseURL = getEnvOrDefault("OPENAI_BASE_URL", "https://api.openai.com/v1")
+		cfg.GenerationModel = getEnvOrDefault("OPENAI_MODEL", "gpt-4o-mini")
+		cfg.EmbeddingModel = getEnvOrDefault("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small")
+		cfg.EmbeddingDim = 1536
+	case LLMProviderOllama:
+		cfg.BaseURL = getEnvOrDefault("OLLAMA_BASE_URL", "http://localhost:11434/v1")
+		cfg.GenerationModel = getEnvOrDefault("OLLAMA_MODEL", "llama3.1")
+		cfg.EmbeddingModel = getEnvOrDefault("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text")
+		cfg.EmbeddingDim = 768
+	default:
+		return LLMConfig{}, fmt.Errorf("unknown llm provider %q", provider)
+	}
+	return cfg, nil
+}
@@ -20,6 +20,24 @@ var (
 	ErrSummaryNotFound  = errors.New("summary not found")
 )
 
+// Vector analysis errors
+var (
+	ErrVectorAnalysis = errors.New("vector analysis failed")
+)
+
+// Upload errors
+var (
+	ErrNoFiles             = errors.New("no files provided")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrFileTooLarge        = errors.New("file exceeds size limit")
+)
+
+// Processing errors
+var (
+	ErrInvalidStatusTransition = errors.New("invalid processing status transition")
+	ErrUnknownChunkingStrategy = errors.New("unknown chunking strategy")
+)
+
 // Vector store errors
 var (
 	ErrCollectionNotFound = errors.New("collection not found")
@@ -27,6 +45,7 @@ var (
 	ErrInvalidVectorSize  = errors.New("invalid vector size")
 	ErrPointNotFound      = errors.New("point not found in collection")
 	ErrInvalidEmbedding   = errors.New("invalid embedding vector")
+	ErrUnsupportedFilter  = errors.New("unsupported payload filter")
 )
 
 // LLM service errors
@@ -36,6 +55,13 @@ var (
 	ErrTokenLimitExceeded  = errors.New("text exceeds token limit")
 )
 
+// HTTPStatusError is implemented by provider errors that carry the HTTP status of a failed call,
+// so callers can tell rate limiting and server failures apart from bad requests
+type HTTPStatusError interface {
+	error
+	HTTPStatus() int
+}
+
 // NewErrInvalidVectorSize creates a new error for invalid vector size
 func NewErrInvalidVectorSize(expected, got uint64) error {
 	return fmt.Errorf("%w: expected %d, got %d", ErrInvalidVectorSize, expected, got)
@@ -43,10 +69,25 @@ func NewErrInvalidVectorSize(expected, got uint64) error {
 
 // NewErrEmbeddingGeneration creates a new error for embedding generation failure
 func NewErrEmbeddingGeneration(cause error) error {
-	return fmt.Errorf("%w: %v", ErrEmbeddingGeneration, cause)
+	return fmt.Errorf("%w: %w", ErrEmbeddingGeneration, cause)
+}
+
+// NewErrTokenLimitExceeded creates a new error for text that does not fit a model call
+func NewErrTokenLimitExceeded(tokens, limit int) error {
+	return fmt.Errorf("%w: %d tokens, limit %d", ErrTokenLimitExceeded, tokens, limit)
 }
 
 // NewErrSummaryGeneration creates a new error for summary generation failure
 func NewErrSummaryGeneration(cause error) error {
-	return fmt.Errorf("%w: %v", ErrSummaryGeneration, cause)
+	return fmt.Errorf("%w: %w", ErrSummaryGeneration, cause)
+}
+
+// NewErrInvalidStatusTransition creates a new error for a disallowed status change
+func NewErrInvalidStatusTransition(from, to ProcessingStatus) error {
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, to)
+}
+
+// NewErrVectorAnalysis creates a new error for reduction or clustering failure
+func NewErrVectorAnalysis(cause error) error {
+	return fmt.Errorf("%w: %w", ErrVectorAnalysis, cause)
 }
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -35,10 +36,99 @@ type Chunk struct {
 	TokenCount int       `json:"token_count"`
 	Embedding  []float32 `json:"embedding,omitempty"`
 	Keywords   []string  `json:"keywords,omitempty"`
+	// Span locates Text in the original document; Text is the exact source text it covers.
+	Span SourceSpan `json:"span"`
+	// HeadingPath is the chain of markdown headings enclosing the chunk, e.g. "Intro > Setup".
+	HeadingPath string `json:"heading_path,omitempty"`
+	// Level is the granularity of the chunk in the document's chunk tree.
+	Level ChunkLevel `json:"level"`
+	// ParentID is the ID of the enclosing chunk one level up; empty for top-level chunks,
+	// which sit directly under the document summary.
+	ParentID string `json:"parent_id,omitempty"`
+	// Overlap is the byte range of Text repeated from the end of the previous chunk (Index-1),
+	// nil when chunks do not overlap. Clients can skip it when showing adjacent chunks together.
+	Overlap *TextRange `json:"overlap,omitempty"`
 	// Visualization data (to be used later)
 	Coord2D    *[2]float32 `json:"coord_2d,omitempty"`
 	Coord3D    *[3]float32 `json:"coord_3d,omitempty"`
 	ClusterIDs []int       `json:"cluster_ids,omitempty"`
+	// ClusterProbabilities[i] is the membership probability of ClusterIDs[i].
+	// Both are ordered by descending probability, so ClusterIDs[0] is the primary cluster.
+	ClusterProbabilities []float64 `json:"cluster_probabilities,omitempty"`
+}
+
+// ChunkLevel is the granularity of a chunk. From coarse to fine a chunk tree holds sections,
+// paragraphs and sentences under the document summary; a flat chunking has paragraphs only.
+type ChunkLevel string
+
+const (
+	// ChunkLevelSection spans all paragraphs under one markdown heading path.
+	ChunkLevelSection ChunkLevel = "section"
+	// ChunkLevelParagraph is a chunk-budget window of text, the level used by flat chunking.
+	ChunkLevelParagraph ChunkLevel = "paragraph"
+	// ChunkLevelSentence is a single sentence of a paragraph.
+	ChunkLevelSentence ChunkLevel = "sentence"
+)
+
+// SourceSpan locates text in a source document: the half-open byte range [StartByte, EndByte)
+// and the 1-based lines of its first and last bytes
+type SourceSpan struct {
+	StartByte int `json:"start_byte"`
+	EndByte   int `json:"end_byte"`
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// TextRange is a half-open byte range [Start, End) within a text
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Keyword is a keyword phrase and its relevance score; higher is more relevant
+type Keyword struct {
+	Text  string
+	Score float64
+}
+
+// KeywordTexts returns the phrases of keywords, in order
+func KeywordTexts(keywords []Keyword) []string {
+	texts := make([]string, len(keywords))
+	for i, kw := range keywords {
+		texts[i] = kw.Text
+	}
+	return texts
+}
+
+// TermStats counts the documents of a space, and for each keyword phrase the documents containing it
+type TermStats struct {
+	Documents         int
+	DocumentFrequency map[string]int
+}
+
+// ChunkingStrategy selects how plain-text documents are cut into segments
+type ChunkingStrategy string
+
+const (
+	// ChunkingFixed packs sentences into windows of up to the token budget.
+	ChunkingFixed ChunkingStrategy = "fixed"
+	// ChunkingSemantic cuts where the topic shifts between sentences.
+	ChunkingSemantic ChunkingStrategy = "semantic"
+)
+
+// IngestOptions tunes the ingestion of one document; zero values select the configured defaults
+type IngestOptions struct {
+	Chunking ChunkingStrategy
+}
+
+// Validate checks that the options name known settings
+func (o IngestOptions) Validate() error {
+	switch o.Chunking {
+	case "", ChunkingFixed, ChunkingSemantic:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownChunkingStrategy, o.Chunking)
+	}
 }
 
 // Summary represents an AI-generated summary of a document
@@ -53,6 +143,21 @@ type Summary struct {
 	ClusterID *int        `json:"cluster_id,omitempty"`
 }
 
+// PartialSummary is an intermediate summary of a map-reduce summarization. Level 1 summarizes
+// chunks, and each further level summarizes partial summaries of the level below.
+type PartialSummary struct {
+	ID    string
+	Level int
+	Text  string
+	// SourceIDs are the chunks (level 1) or partial summaries (above) this one summarizes, in order.
+	SourceIDs []string
+	// ParentID is the partial summary one level up that summarizes this one, or the document
+	// summary for the top level.
+	ParentID string
+	// Embedding is set once the partial summary has been embedded for indexing.
+	Embedding []float32
+}
+
 // SearchResult is a vector search hit, used for similarity search results.
 type SearchResult struct {
 	ID    string
@@ -60,10 +165,74 @@ type SearchResult struct {
 	Meta  map[string]interface{}
 }
 
+// Distance is the similarity metric of a vector collection
+type Distance string
+
+const (
+	// DistanceCosine scores by cosine similarity; higher is more similar.
+	DistanceCosine Distance = "Cosine"
+	// DistanceDot scores by dot product; higher is more similar.
+	DistanceDot Distance = "Dot"
+	// DistanceEuclid scores by Euclidean distance; lower is more similar.
+	DistanceEuclid Distance = "Euclid"
+)
+
+// PayloadFilter restricts a search to points whose payload matches every entry.
+// Values may be strings, booleans or integers; a list-valued payload field matches
+// when any of its elements equals the filter value.
+type PayloadFilter map[string]interface{}
+
 // Cluster groups items for visualization and clustering results.
+// Probabilities, when set, holds the membership probability of each entry of MemberIDs;
+// nil means hard membership (every member has probability 1).
 type Cluster struct {
-	Label     int
-	MemberIDs []string
+	Label         int
+	MemberIDs     []string
+	Probabilities []float64
+}
+
+// Probability returns the membership probability of the i-th member
+func (c Cluster) Probability(i int) float64 {
+	if c.Probabilities == nil {
+		return 1
+	}
+	return c.Probabilities[i]
+}
+
+// ClusterOptions tunes a clustering call.
+// A member is listed in every cluster whose probability is at least MinProbability,
+// and always in its most probable cluster. Zero keeps clustering hard (one cluster per member).
+type ClusterOptions struct {
+	MinProbability float64
+}
+
+// StreamChunk is one piece of a streamed generation: a text delta, or the error that ended
+// the stream early
+type StreamChunk struct {
+	Delta string
+	Err   error
+}
+
+// CanTransitionTo reports whether a document in status s may move to next.
+// Completed and failed documents may be sent back to processing so they can be re-ingested.
+func (s ProcessingStatus) CanTransitionTo(next ProcessingStatus) bool {
+	switch next {
+	case StatusProcessing:
+		return s == StatusUploaded || s == StatusCompleted || s == StatusFailed
+	case StatusCompleted, StatusFailed:
+		return s == StatusProcessing
+	default:
+		return false
+	}
+}
+
+// TransitionTo moves the document to the next processing status
+func (d *Document) TransitionTo(next ProcessingStatus) error {
+	if !d.Status.CanTransitionTo(next) {
+		return NewErrInvalidStatusTransition(d.Status, next)
+	}
+	d.Status = next
+	return nil
 }
 
 // Validate checks if the Document struct has all required fields
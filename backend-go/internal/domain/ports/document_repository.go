@@ -0,0 +1,29 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// DocumentRepository persists documents and their processing state
+type DocumentRepository interface {
+	// Save inserts the document or replaces the stored document with the same ID.
+	Save(ctx context.Context, doc domain.Document) error
+	// Get returns the document with the given ID, or domain.ErrDocumentNotFound.
+	Get(ctx context.Context, id string) (domain.Document, error)
+	// List returns all stored documents ordered by creation time.
+	List(ctx context.Context) ([]domain.Document, error)
+	// Delete removes the document with the given ID; deleting a missing document is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// TermStatsRepository keeps the keyword phrases of each document of a space, from which keyword
+// extraction weighs terms by their document frequency across the space
+type TermStatsRepository interface {
+	// Stats returns the term statistics of space, leaving out document excludeID (e.g. the
+	// document being re-ingested).
+	Stats(ctx context.Context, space, excludeID string) (domain.TermStats, error)
+	// SaveTerms records the distinct phrases of a document, replacing those recorded before.
+	SaveTerms(ctx context.Context, space, documentID string, terms []string) error
+}
@@ -1,7 +1,9 @@
-package domain_ports
+package ports
 
 import (
 	"context"
+
+	"github.com/ran/demo/backend-go/internal/domain"
 )
 
 // LLM defines operations for LLM-based text processing (e.g. summarization, completion, Q&A, extraction)
@@ -19,6 +21,19 @@ type LLM interface {
 	ExtractKeywords(ctx context.Context, text string) ([]string, error)
 }
 
+// StreamingLLM is implemented by LLMs that can emit their output while it is generated.
+// The returned channel delivers text deltas and is closed when generation ends; a failure after
+// the stream started arrives as a final chunk with Err set. Cancelling ctx stops generation.
+type StreamingLLM interface {
+	LLM
+
+	// StreamCompletion streams the completion of the given prompt.
+	StreamCompletion(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error)
+
+	// StreamAnswer streams the answer to a question given its context passages.
+	StreamAnswer(ctx context.Context, context []string, question string) (<-chan domain.StreamChunk, error)
+}
+
 // EmbeddingModel defines operations for generating vector embeddings
 type EmbeddingModel interface {
 	// GenerateEmbedding generates a vector embedding for the given text.
@@ -30,3 +45,23 @@ type EmbeddingModel interface {
 	// GetEmbeddingDimension returns the dimension of the embeddings generated by this service.
 	GetEmbeddingDimension() uint64
 }
+
+// Tokenizer estimates how many model tokens a text costs
+type Tokenizer interface {
+	// CountTokens returns the number of tokens text is split into.
+	CountTokens(text string) int
+}
+
+// KeywordExtractor extracts keywords locally, without an LLM call
+type KeywordExtractor interface {
+	// ExtractKeywords extracts keywords from a single text.
+	ExtractKeywords(ctx context.Context, text string) ([]string, error)
+
+	// ExtractCorpusKeywords extracts scored keywords for each of texts, the chunks of one document,
+	// best first. Terms are weighted by how distinctive they are among texts and, through stats,
+	// among the documents of the space; the document itself counts on top of stats.
+	ExtractCorpusKeywords(ctx context.Context, texts []string, stats domain.TermStats) ([][]domain.Keyword, error)
+
+	// Terms returns the distinct candidate phrases of texts, as counted in domain.TermStats.
+	Terms(ctx context.Context, texts []string) ([]string, error)
+}
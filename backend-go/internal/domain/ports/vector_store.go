@@ -3,24 +3,43 @@ package ports
 import (
 	"context"
 
-	"github.com/ran/demo/backend-go/internal/domain/models"
+	"github.com/ran/demo/backend-go/internal/domain"
 )
 
 // Use core models for domain entities
 
 // DocumentUploader handles file ingestion
 type DocumentUploader interface {
-	Upload(ctx context.Context, filePaths []string) ([]models.Document, error)
+	Upload(ctx context.Context, filePaths []string) ([]domain.Document, error)
 }
 
-// VectorStoreService defines operations for segmenting text, indexing vectors, and searching.
+// DocumentProcessor runs uploaded documents through the ingestion pipeline
+type DocumentProcessor interface {
+	// Process ingests doc with opts, saving its final processing state.
+	Process(ctx context.Context, doc domain.Document, opts domain.IngestOptions) error
+}
+
+// VectorStoreService defines operations for indexing vectors and searching.
+// Text segmentation lives in the service layer (see service.SegmentText).
 type VectorStoreService interface {
-	// Segment splits a Document into smaller Chunks.
-	Segment(ctx context.Context, doc models.Document, maxTokens int) ([]models.Chunk, error)
+	// EnsureCollection creates the collection if it does not exist yet.
+	EnsureCollection(ctx context.Context, collection string, vectorSize uint64, distance domain.Distance) error
 	// Index indexes embeddings and metadata into a storage backend.
 	Index(ctx context.Context, collection string, id string, vector []float32, meta map[string]interface{}) error
 	// Search performs similarity search over indexed vectors.
-	Search(ctx context.Context, collection string, vector []float32, topK int) ([]models.SearchResult, error)
+	Search(ctx context.Context, collection string, vector []float32, topK int) ([]domain.SearchResult, error)
+	// SearchWithFilter performs similarity search restricted to points matching the payload filter.
+	SearchWithFilter(ctx context.Context, collection string, vector []float32, topK int, filter domain.PayloadFilter) ([]domain.SearchResult, error)
+	// Get looks up an indexed vector's metadata by ID, returning domain.ErrPointNotFound if absent.
+	Get(ctx context.Context, collection string, id string) (domain.SearchResult, error)
+	// Delete removes indexed vectors by ID; unknown IDs are ignored.
+	Delete(ctx context.Context, collection string, ids []string) error
+	// IndexChunks writes the chunks of document documentID with their embeddings, coordinates
+	// and metadata, then drops the document's chunks from earlier runs that are not among them.
+	IndexChunks(ctx context.Context, collection string, documentID string, chunks []domain.Chunk) error
+	// IndexSummaries writes the summary of doc, listing its chunkIDs, and its partial summaries,
+	// then drops the document's summaries from earlier runs that are not among them.
+	IndexSummaries(ctx context.Context, collection string, doc domain.Document, summary domain.Summary, chunkIDs []string, partials []domain.PartialSummary) error
 }
 
 // VectorAnalysisService provides vector analysis capabilities such as dimensionality reduction and clustering for visualization and grouping.
@@ -28,5 +47,8 @@ type VectorAnalysisService interface {
 	// Reduce reduces high-dimensional vectors for visualization (e.g., UMAP, PCA).
 	Reduce(ctx context.Context, vectors [][]float32) ([][]float32, error)
 	// Cluster groups vectors into clusters for visual distinction (e.g., K-Means, HDBSCAN).
-	Cluster(ctx context.Context, vectors [][]float32) ([]models.Cluster, error)
+	// MemberIDs of each cluster are the positions of the input vectors, formatted as decimal strings.
+	// With opts.MinProbability > 0 a vector may appear in several clusters, and each cluster
+	// reports the membership probabilities of its members (soft clustering, e.g. GMM).
+	Cluster(ctx context.Context, vectors [][]float32, opts domain.ClusterOptions) ([]domain.Cluster, error)
 }
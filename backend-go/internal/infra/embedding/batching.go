@@ -0,0 +1,225 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+// Config holds the provider limits a BatchingModel works within. Zero values select the defaults
+// noted on each field.
+type Config struct {
+	// MaxBatchSize is the largest number of texts per request (default 100).
+	MaxBatchSize int
+	// MaxBatchTokens is the largest total token count per request (default unlimited).
+	// A single text over the limit is still sent, alone.
+	MaxBatchTokens int
+	// Concurrency bounds the requests in flight (default 4).
+	Concurrency int
+	// RequestsPerMinute rate-limits requests with a token bucket (default unlimited).
+	RequestsPerMinute int
+	// Burst is the number of requests the bucket admits at once (default 1).
+	Burst int
+	// MaxRetries is the number of retries of a request that failed with 429, 5xx or a
+	// transport error (default 3; negative disables retries).
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles on each further attempt,
+	// up to MaxBackoff (defaults 500ms and 30s).
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	// Tokenizer counts tokens for MaxBatchTokens (default the character-based estimate).
+	Tokenizer ports.Tokenizer
+}
+
+// BatchingModel decorates an EmbeddingModel to respect provider limits: it splits batches by
+// count and tokens, sends them concurrently under a rate limit, retries transient failures with
+// exponential backoff and returns the vectors in input order.
+type BatchingModel struct {
+	inner   ports.EmbeddingModel
+	cfg     Config
+	limiter *tokenBucket
+}
+
+// NewBatchingModel wraps inner with batching, rate limiting and retries
+func NewBatchingModel(inner ports.EmbeddingModel, cfg Config) (*BatchingModel, error) {
+	if inner == nil {
+		return nil, errors.New("embedding model is required")
+	}
+	if cfg.MaxBatchSize < 0 || cfg.MaxBatchTokens < 0 || cfg.Concurrency < 0 || cfg.RequestsPerMinute < 0 || cfg.Burst < 0 {
+		return nil, errors.New("embedding batch limits must not be negative")
+	}
+	if cfg.MaxBatchSize == 0 {
+		cfg.MaxBatchSize = 100
+	}
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = 1
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.Tokenizer == nil {
+		cfg.Tokenizer = tokenizer.NewCharTokenizer()
+	}
+	m := &BatchingModel{inner: inner, cfg: cfg}
+	if cfg.RequestsPerMinute > 0 {
+		m.limiter = newTokenBucket(float64(cfg.RequestsPerMinute)/60, cfg.Burst)
+	}
+	return m, nil
+}
+
+// GenerateEmbedding embeds a single text under the rate limit, with retries
+func (m *BatchingModel) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := m.call(ctx, func() error {
+		var err error
+		vector, err = m.inner.GenerateEmbedding(ctx, text)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vector, nil
+}
+
+// GenerateEmbeddings embeds texts in as many requests as the limits require. The first failed
+// batch cancels the others and its error is returned.
+func (m *BatchingModel) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	vectors := make([][]float32, len(texts))
+	sem := make(chan struct{}, m.cfg.Concurrency)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for _, b := range m.batches(texts) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		// select picks at random when both cases are ready, so check ctx either way
+		if err := ctx.Err(); err != nil {
+			fail(err)
+			break
+		}
+		wg.Add(1)
+		go func(b batch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var out [][]float32
+			err := m.call(ctx, func() error {
+				var err error
+				out, err = m.inner.GenerateEmbeddings(ctx, texts[b.start:b.end])
+				return err
+			})
+			if err == nil && len(out) != b.end-b.start {
+				err = domain.NewErrEmbeddingGeneration(fmt.Errorf("expected %d embeddings, got %d", b.end-b.start, len(out)))
+			}
+			if err != nil {
+				fail(fmt.Errorf("failed to embed texts %d-%d: %w", b.start, b.end-1, err))
+				return
+			}
+			copy(vectors[b.start:b.end], out)
+		}(b)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return vectors, nil
+}
+
+// GetEmbeddingDimension returns the dimension of the wrapped model
+func (m *BatchingModel) GetEmbeddingDimension() uint64 {
+	return m.inner.GetEmbeddingDimension()
+}
+
+// batch is the half-open range [start, end) of texts sent in one request
+type batch struct {
+	start, end int
+}
+
+// batches splits texts into consecutive batches within MaxBatchSize and MaxBatchTokens
+func (m *BatchingModel) batches(texts []string) []batch {
+	var out []batch
+	start, tokens := 0, 0
+	for i, text := range texts {
+		n := 0
+		if m.cfg.MaxBatchTokens > 0 {
+			n = m.cfg.Tokenizer.CountTokens(text)
+		}
+		full := i-start == m.cfg.MaxBatchSize || (m.cfg.MaxBatchTokens > 0 && tokens+n > m.cfg.MaxBatchTokens)
+		if i > start && full {
+			out = append(out, batch{start, i})
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(texts) {
+		out = append(out, batch{start, len(texts)})
+	}
+	return out
+}
+
+// call runs fn under the rate limit, retrying transient failures with exponential backoff
+func (m *BatchingModel) call(ctx context.Context, fn func() error) error {
+	backoff := m.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if m.limiter != nil {
+			if err := m.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		err := fn()
+		if err == nil || attempt >= m.cfg.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, m.cfg.MaxBackoff)
+	}
+}
+
+// retryable reports whether err is worth another attempt: transport failures, 429 and 5xx
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr domain.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		status := statusErr.HTTPStatus()
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
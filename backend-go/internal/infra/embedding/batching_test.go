@@ -0,0 +1,198 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) HTTPStatus() int { return int(e) }
+
+// flakyModel embeds a text as its index and fails the first failures calls with err
+type flakyModel struct {
+	err      error
+	failures int
+	delay    time.Duration
+
+	mu       sync.Mutex
+	calls    int
+	batches  [][]string
+	inFlight int
+	peak     int
+}
+
+func (m *flakyModel) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	out, err := m.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (m *flakyModel) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	m.mu.Lock()
+	m.calls++
+	fail := m.calls <= m.failures
+	if !fail {
+		m.batches = append(m.batches, texts)
+	}
+	m.inFlight++
+	m.peak = max(m.peak, m.inFlight)
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.inFlight--
+		m.mu.Unlock()
+	}()
+
+	time.Sleep(m.delay)
+	if fail {
+		return nil, domain.NewErrEmbeddingGeneration(m.err)
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = []float32{float32(n)}
+	}
+	return out, nil
+}
+
+func (m *flakyModel) GetEmbeddingDimension() uint64 { return 1 }
+
+func numbered(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	return texts
+}
+
+func TestBatchingModelSplitsAndKeepsOrder(t *testing.T) {
+	inner := &flakyModel{delay: 5 * time.Millisecond}
+	m, err := NewBatchingModel(inner, Config{MaxBatchSize: 3, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("NewBatchingModel failed: %v", err)
+	}
+	vectors, err := m.GenerateEmbeddings(context.Background(), numbered(10))
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i) {
+			t.Errorf("vector %d = %v, results out of order", i, v)
+		}
+	}
+	if len(inner.batches) != 4 {
+		t.Errorf("got %d batches, want 4", len(inner.batches))
+	}
+	if inner.peak > 2 {
+		t.Errorf("%d requests in flight, concurrency is 2", inner.peak)
+	}
+}
+
+func TestBatchingModelTokenBudget(t *testing.T) {
+	m, err := NewBatchingModel(&flakyModel{}, Config{MaxBatchTokens: 4})
+	if err != nil {
+		t.Fatalf("NewBatchingModel failed: %v", err)
+	}
+	got := m.batches([]string{"a b", "c d", "e", "f g h i j", "k"})
+	want := []batch{{0, 2}, {2, 3}, {3, 4}, {4, 5}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("batches = %v, want %v", got, want)
+	}
+}
+
+func TestBatchingModelRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		failures  int
+		wantErr   bool
+		wantCalls int
+	}{
+		{"rate limited then ok", statusError(http.StatusTooManyRequests), 2, false, 3},
+		{"server error then ok", statusError(http.StatusBadGateway), 1, false, 2},
+		{"retries exhausted", statusError(http.StatusServiceUnavailable), 5, true, 4},
+		{"bad request is not retried", statusError(http.StatusBadRequest), 1, true, 1},
+		{"other errors are not retried", errors.New("boom"), 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyModel{err: tt.err, failures: tt.failures}
+			m, err := NewBatchingModel(inner, Config{MaxRetries: 3, RetryBackoff: time.Millisecond})
+			if err != nil {
+				t.Fatalf("NewBatchingModel failed: %v", err)
+			}
+			_, err = m.GenerateEmbeddings(context.Background(), numbered(2))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrEmbeddingGeneration) {
+				t.Errorf("error should wrap the model error, got %v", err)
+			}
+			if inner.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", inner.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBatchingModelCancelledContext(t *testing.T) {
+	m, err := NewBatchingModel(&flakyModel{}, Config{MaxBatchSize: 1, Concurrency: 4})
+	if err != nil {
+		t.Fatalf("NewBatchingModel failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		vectors, err := m.GenerateEmbeddings(ctx, numbered(3))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("run %d: got %d vectors and err %v, want context.Canceled", i, len(vectors), err)
+		}
+	}
+}
+
+func TestBatchingModelRateLimit(t *testing.T) {
+	inner := &flakyModel{}
+	m, err := NewBatchingModel(inner, Config{MaxBatchSize: 1, RequestsPerMinute: 1200})
+	if err != nil {
+		t.Fatalf("NewBatchingModel failed: %v", err)
+	}
+	start := time.Now()
+	if _, err := m.GenerateEmbeddings(context.Background(), numbered(5)); err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	// 20 requests per second with a burst of one: the last of five waits for four refills
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("5 requests took %v, rate limit not applied", elapsed)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(2, 2)
+	b.now = func() time.Time { return now }
+	if b.reserve() != 0 || b.reserve() != 0 {
+		t.Fatal("burst of two should be admitted at once")
+	}
+	if d := b.reserve(); d != 500*time.Millisecond {
+		t.Errorf("third request should wait 500ms, got %v", d)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if d := b.reserve(); d != 0 {
+		t.Errorf("token should have refilled, got wait %v", d)
+	}
+}
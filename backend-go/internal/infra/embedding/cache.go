@@ -0,0 +1,158 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// CacheStats counts cache lookups since the CachedModel was created
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// CachedModel decorates an EmbeddingModel with a content-addressed cache. Entries are keyed by
+// the model name, its dimension and a hash of the whitespace-normalized text, so identical text
+// is embedded once however many documents or spaces contain it. Entries written for another
+// model or dimension are dropped when the CachedModel is created.
+type CachedModel struct {
+	inner     ports.EmbeddingModel
+	store     CacheStore
+	namespace string
+	served    ServedCheck
+	hits      atomic.Int64
+	misses    atomic.Int64
+}
+
+// ServedCheck wraps the context of a call to the inner model and returns a function reporting,
+// once the call is done, whether its vectors came from the model the cache is named after. It
+// lets a model that falls back to other providers, such as a fallback chain, keep their vectors
+// out of the cache.
+type ServedCheck func(ctx context.Context) (context.Context, func() bool)
+
+// NewCachedModel wraps inner, named modelName, with a cache held in store. With a non-nil
+// served, vectors are cached only when served reports they came from modelName.
+func NewCachedModel(inner ports.EmbeddingModel, modelName string, store CacheStore, served ServedCheck) (*CachedModel, error) {
+	if inner == nil || store == nil {
+		return nil, errors.New("embedding model and cache store are required")
+	}
+	if modelName == "" {
+		return nil, errors.New("embedding model name is required")
+	}
+	m := &CachedModel{inner: inner, store: store, namespace: cacheNamespace(modelName, inner.GetEmbeddingDimension()), served: served}
+	if err := store.Retain(m.namespace); err != nil {
+		return nil, fmt.Errorf("failed to invalidate stale embedding cache: %w", err)
+	}
+	return m, nil
+}
+
+// GenerateEmbedding returns the cached vector of text, embedding it on a miss
+func (m *CachedModel) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := m.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// GenerateEmbeddings returns the cached vectors of texts, embedding all misses in one call.
+// Texts that normalize to the same string are embedded once.
+func (m *CachedModel) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	pending := make(map[string][]int) // key -> positions awaiting its vector
+	var missTexts, missKeys []string
+	for i, text := range texts {
+		keys[i] = m.key(text)
+		if v, ok := m.store.Get(keys[i]); ok {
+			vectors[i] = v
+			m.hits.Add(1)
+			continue
+		}
+		m.misses.Add(1)
+		if _, ok := pending[keys[i]]; !ok {
+			missTexts = append(missTexts, text)
+			missKeys = append(missKeys, keys[i])
+		}
+		pending[keys[i]] = append(pending[keys[i]], i)
+	}
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	fromModel := func() bool { return true }
+	if m.served != nil {
+		ctx, fromModel = m.served(ctx)
+	}
+	embedded, err := m.inner.GenerateEmbeddings(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missTexts) {
+		return nil, domain.NewErrEmbeddingGeneration(fmt.Errorf("expected %d embeddings, got %d", len(missTexts), len(embedded)))
+	}
+	entries := make(map[string][]float32, len(missKeys))
+	dim := m.inner.GetEmbeddingDimension()
+	for j, key := range missKeys {
+		if uint64(len(embedded[j])) != dim {
+			return nil, domain.NewErrInvalidVectorSize(dim, uint64(len(embedded[j])))
+		}
+		entries[key] = embedded[j]
+		for n, i := range pending[key] {
+			if n == 0 {
+				vectors[i] = embedded[j]
+			} else {
+				vectors[i] = slices.Clone(embedded[j]) // every position gets its own vector
+			}
+		}
+	}
+	if !fromModel() {
+		return vectors, nil
+	}
+	if err := m.store.Put(entries); err != nil {
+		return nil, fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	return vectors, nil
+}
+
+// GetEmbeddingDimension returns the dimension of the wrapped model
+func (m *CachedModel) GetEmbeddingDimension() uint64 {
+	return m.inner.GetEmbeddingDimension()
+}
+
+// Stats returns the hit and miss counts and the number of cached vectors
+func (m *CachedModel) Stats() CacheStats {
+	return CacheStats{Hits: m.hits.Load(), Misses: m.misses.Load(), Entries: m.store.Len()}
+}
+
+// Invalidate drops every cached vector
+func (m *CachedModel) Invalidate() error {
+	return m.store.Clear()
+}
+
+// key is the namespace followed by the hash of the normalized text
+func (m *CachedModel) key(text string) string {
+	sum := sha256.Sum256([]byte(normalizeText(text)))
+	return m.namespace + hex.EncodeToString(sum[:])
+}
+
+// cacheNamespace is the key prefix of a model and dimension
+func cacheNamespace(modelName string, dim uint64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", modelName, dim)))
+	return hex.EncodeToString(sum[:8]) + ":"
+}
+
+// normalizeText trims text and collapses runs of whitespace, which do not change its meaning
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
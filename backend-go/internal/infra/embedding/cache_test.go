@@ -0,0 +1,197 @@
+package embedding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// lengthModel embeds a text as {len(text), 1} and records every text it is asked for
+type lengthModel struct {
+	dim   uint64
+	asked []string
+}
+
+func (m *lengthModel) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	out, err := m.GenerateEmbeddings(ctx, []string{text})
+	return out[0], err
+}
+
+func (m *lengthModel) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	m.asked = append(m.asked, texts...)
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, m.dim)
+		v[0] = float32(len(text))
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (m *lengthModel) GetEmbeddingDimension() uint64 { return m.dim }
+
+func TestCachedModel(t *testing.T) {
+	inner := &lengthModel{dim: 2}
+	m, err := NewCachedModel(inner, "models/embed", NewMemoryCacheStore(), nil)
+	if err != nil {
+		t.Fatalf("NewCachedModel failed: %v", err)
+	}
+	ctx := context.Background()
+	first, err := m.GenerateEmbeddings(ctx, []string{"alpha beta", "gamma", "alpha  beta\n"})
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	if !reflect.DeepEqual(inner.asked, []string{"alpha beta", "gamma"}) {
+		t.Errorf("texts equal after normalization should be embedded once, asked %q", inner.asked)
+	}
+	if !reflect.DeepEqual(first[0], first[2]) {
+		t.Errorf("duplicate texts got different vectors: %v", first)
+	}
+
+	second, err := m.GenerateEmbeddings(ctx, []string{"gamma", "delta"})
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	if !reflect.DeepEqual(second[0], first[1]) || len(inner.asked) != 3 {
+		t.Errorf("cached text was embedded again, asked %q", inner.asked)
+	}
+	if got, want := m.Stats(), (CacheStats{Hits: 1, Misses: 4, Entries: 3}); got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+
+	if err := m.Invalidate(); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if _, err := m.GenerateEmbedding(ctx, "gamma"); err != nil || len(inner.asked) != 4 {
+		t.Errorf("invalidated entry should be embedded again, asked %q (err %v)", inner.asked, err)
+	}
+}
+
+func TestCachedModelSkipsVectorsFromAnotherModel(t *testing.T) {
+	inner := &lengthModel{dim: 2}
+	fromPrimary := false
+	served := func(ctx context.Context) (context.Context, func() bool) {
+		return ctx, func() bool { return fromPrimary }
+	}
+	m, err := NewCachedModel(inner, "models/embed", NewMemoryCacheStore(), served)
+	if err != nil {
+		t.Fatalf("NewCachedModel failed: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := m.GenerateEmbedding(ctx, "alpha"); err != nil {
+		t.Fatalf("GenerateEmbedding failed: %v", err)
+	}
+	if got := m.Stats().Entries; got != 0 {
+		t.Errorf("cached %d vectors of another model, want none", got)
+	}
+	fromPrimary = true
+	for range 2 {
+		if _, err := m.GenerateEmbedding(ctx, "alpha"); err != nil {
+			t.Fatalf("GenerateEmbedding failed: %v", err)
+		}
+	}
+	if len(inner.asked) != 2 {
+		t.Errorf("vector of the named model was not cached, asked %q", inner.asked)
+	}
+}
+
+func TestCachedModelReturnsCopies(t *testing.T) {
+	inner := &lengthModel{dim: 2}
+	m, err := NewCachedModel(inner, "models/embed", NewMemoryCacheStore(), nil)
+	if err != nil {
+		t.Fatalf("NewCachedModel failed: %v", err)
+	}
+	ctx := context.Background()
+	first, err := m.GenerateEmbeddings(ctx, []string{"abc", "abc"})
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	first[0][0] = -1 // e.g. normalized in place by the caller
+	if first[1][0] != 3 {
+		t.Errorf("duplicate positions share a vector: %v", first)
+	}
+	hit, err := m.GenerateEmbedding(ctx, "abc")
+	if err != nil {
+		t.Fatalf("GenerateEmbedding failed: %v", err)
+	}
+	hit[1] = -1
+	again, _ := m.GenerateEmbedding(ctx, "abc")
+	if !reflect.DeepEqual(again, []float32{3, 0}) {
+		t.Errorf("changes to returned vectors leaked into the cache: %v", again)
+	}
+}
+
+func TestDiskCacheStorePersistsAndInvalidates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "embeddings.bin")
+	ctx := context.Background()
+	open := func(model string, dim uint64) (*CachedModel, *lengthModel) {
+		t.Helper()
+		store, err := NewDiskCacheStore(path)
+		if err != nil {
+			t.Fatalf("NewDiskCacheStore failed: %v", err)
+		}
+		inner := &lengthModel{dim: dim}
+		m, err := NewCachedModel(inner, model, store, nil)
+		if err != nil {
+			t.Fatalf("NewCachedModel failed: %v", err)
+		}
+		return m, inner
+	}
+
+	m, _ := open("model-a", 2)
+	if _, err := m.GenerateEmbeddings(ctx, []string{"one", "two"}); err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+
+	m, inner := open("model-a", 2)
+	if _, err := m.GenerateEmbeddings(ctx, []string{"one", "two"}); err != nil || len(inner.asked) != 0 {
+		t.Errorf("reopened cache should serve both texts, asked %q (err %v)", inner.asked, err)
+	}
+
+	for _, changed := range []struct {
+		model string
+		dim   uint64
+	}{{"model-a", 3}, {"model-b", 3}} {
+		m, inner = open(changed.model, changed.dim)
+		if m.Stats().Entries != 0 {
+			t.Errorf("%s/%d: entries of another model or dimension should be dropped", changed.model, changed.dim)
+		}
+		if _, err := m.GenerateEmbedding(ctx, "one"); err != nil || len(inner.asked) != 1 {
+			t.Errorf("%s/%d: text should be embedded again, asked %q (err %v)", changed.model, changed.dim, inner.asked, err)
+		}
+	}
+}
+
+func TestDiskCacheStoreDropsTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.bin")
+	store, err := NewDiskCacheStore(path)
+	if err != nil {
+		t.Fatalf("NewDiskCacheStore failed: %v", err)
+	}
+	if err := store.Put(map[string][]float32{"a": {1, 2}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{5, 0, 0, 0, 'b'}) // a record cut short by a crash
+	f.Close()
+
+	store, err = NewDiskCacheStore(path)
+	if err != nil {
+		t.Fatalf("reopening a torn cache failed: %v", err)
+	}
+	if err := store.Put(map[string][]float32{"c": {3}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	store, err = NewDiskCacheStore(path)
+	if err != nil {
+		t.Fatalf("NewDiskCacheStore failed: %v", err)
+	}
+	if v, ok := store.Get("c"); store.Len() != 2 || !ok || v[0] != 3 {
+		t.Errorf("records appended after a torn one were lost: %d entries", store.Len())
+	}
+}
@@ -0,0 +1,299 @@
+package embedding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ran/demo/backend-go/internal/config"
+)
+
+// CacheStore holds cached embeddings under opaque keys
+type CacheStore interface {
+	// Get returns the vector under key. The caller owns the returned slice.
+	Get(key string) ([]float32, bool)
+	// Put stores entries, overwriting existing keys. The store keeps no reference to the vectors.
+	Put(entries map[string][]float32) error
+	// Retain drops every entry whose key does not start with prefix.
+	Retain(prefix string) error
+	// Clear drops every entry.
+	Clear() error
+	Len() int
+}
+
+// NewCacheStore creates the cache backend described by cfg, or nil when caching is disabled
+func NewCacheStore(cfg config.EmbeddingConfig) (CacheStore, error) {
+	switch cfg.CacheBackend {
+	case config.EmbeddingCacheNone:
+		return nil, nil
+	case config.EmbeddingCacheMemory:
+		return NewMemoryCacheStore(), nil
+	case config.EmbeddingCacheDisk:
+		return NewDiskCacheStore(cfg.CachePath)
+	default:
+		return nil, fmt.Errorf("unknown embedding cache backend %q", cfg.CacheBackend)
+	}
+}
+
+// MemoryCacheStore is a CacheStore that lives as long as the process
+type MemoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string][]float32
+}
+
+// NewMemoryCacheStore creates an empty in-memory cache
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string][]float32)}
+}
+
+// Get returns a copy of the vector cached under key
+func (s *MemoryCacheStore) Get(key string) ([]float32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(v), true
+}
+
+// Put stores copies of entries in memory, so callers may go on modifying their vectors
+func (s *MemoryCacheStore) Put(entries map[string][]float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range entries {
+		s.entries[k] = slices.Clone(v)
+	}
+	return nil
+}
+
+// Retain drops entries whose key lacks prefix
+func (s *MemoryCacheStore) Retain(prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.entries {
+		if !strings.HasPrefix(k, prefix) {
+			delete(s.entries, k)
+		}
+	}
+	return nil
+}
+
+// Clear drops all entries
+func (s *MemoryCacheStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string][]float32)
+	return nil
+}
+
+// Len returns the number of cached vectors
+func (s *MemoryCacheStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// DiskCacheStore is a CacheStore kept in memory and backed by an append-only file, so it
+// survives restarts and works offline. Each Put appends its records; Retain and Clear rewrite
+// the file. A record torn by a crash is dropped on load.
+type DiskCacheStore struct {
+	MemoryCacheStore
+	fileMu sync.Mutex
+	path   string
+}
+
+// NewDiskCacheStore opens the cache file at path, creating it on first write
+func NewDiskCacheStore(path string) (*DiskCacheStore, error) {
+	if path == "" {
+		return nil, errors.New("embedding cache path is required")
+	}
+	s := &DiskCacheStore{MemoryCacheStore: *NewMemoryCacheStore(), path: path}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to load embedding cache from %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// Put stores entries and appends them to the cache file
+func (s *DiskCacheStore) Put(entries map[string][]float32) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for k, v := range entries {
+		if err := writeRecord(w, k, v); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.MemoryCacheStore.Put(entries)
+}
+
+// Retain drops entries whose key lacks prefix and compacts the file
+func (s *DiskCacheStore) Retain(prefix string) error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	before := s.Len()
+	if err := s.MemoryCacheStore.Retain(prefix); err != nil {
+		return err
+	}
+	if s.Len() == before {
+		return nil
+	}
+	return s.rewrite()
+}
+
+// Clear drops all entries and truncates the file
+func (s *DiskCacheStore) Clear() error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if err := s.MemoryCacheStore.Clear(); err != nil {
+		return err
+	}
+	return s.rewrite()
+}
+
+// load replays the cache file and truncates a torn trailing record, so later appends stay
+// readable. A missing file means an empty cache.
+func (s *DiskCacheStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := &countingReader{r: bufio.NewReader(f)}
+	for {
+		good := r.n
+		key, vector, err := readRecord(r)
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return os.Truncate(s.path, good)
+		case err != nil:
+			return err
+		}
+		s.entries[key] = vector
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// rewrite atomically replaces the cache file with the current entries. It must be called
+// with fileMu held.
+func (s *DiskCacheStore) rewrite() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	s.mu.RLock()
+	for k, v := range s.entries {
+		if err = writeRecord(w, k, v); err != nil {
+			break
+		}
+	}
+	s.mu.RUnlock()
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// maxRecordLen bounds key and vector lengths read back, so a corrupt file cannot trigger huge allocations
+const maxRecordLen = 1 << 20
+
+// writeRecord writes key and vector as: key length, key bytes, vector length, little-endian float32s
+func writeRecord(w io.Writer, key string, vector []float32) error {
+	buf := make([]byte, 0, 8+len(key)+4*len(vector))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(key)))
+	buf = append(buf, key...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(vector)))
+	for _, x := range vector {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(x))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func readRecord(r io.Reader) (string, []float32, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", nil, err
+	}
+	if n > maxRecordLen {
+		return "", nil, fmt.Errorf("corrupt cache record: key of %d bytes", n)
+	}
+	key := make([]byte, n)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return "", nil, unexpected(err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", nil, unexpected(err)
+	}
+	if n > maxRecordLen {
+		return "", nil, fmt.Errorf("corrupt cache record: vector of %d values", n)
+	}
+	vector := make([]float32, n)
+	if err := binary.Read(r, binary.LittleEndian, vector); err != nil {
+		return "", nil, unexpected(err)
+	}
+	return string(key), vector, nil
+}
+
+// unexpected turns an EOF inside a record into io.ErrUnexpectedEOF
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
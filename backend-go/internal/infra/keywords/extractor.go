@@ -0,0 +1,240 @@
+package keywords
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// DefaultMaxKeywords is the number of keywords returned per text when none is configured
+const DefaultMaxKeywords = 10
+
+// maxPhraseWords caps the length of a candidate phrase; longer runs are cut into pieces
+const maxPhraseWords = 3
+
+// Extractor scores candidate phrases without a model. Candidates are runs of content words
+// between stopwords and punctuation (RAKE); in Japanese, where words are not spaced, runs of
+// kanji, katakana and Latin letters between hiragana and punctuation. A candidate's score
+// combines its RAKE word degrees with YAKE-style frequency and position weights, and, when
+// extracting over a corpus, the inverse document frequencies of the phrase across the texts
+// and across the documents of the space.
+type Extractor struct {
+	maxKeywords int
+}
+
+// NewExtractor creates an extractor returning up to maxKeywords keywords per text;
+// zero or less selects DefaultMaxKeywords
+func NewExtractor(maxKeywords int) *Extractor {
+	if maxKeywords <= 0 {
+		maxKeywords = DefaultMaxKeywords
+	}
+	return &Extractor{maxKeywords: maxKeywords}
+}
+
+// ExtractKeywords returns the top keywords of text, best first
+func (e *Extractor) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	kws, err := e.ExtractCorpusKeywords(ctx, []string{text}, domain.TermStats{})
+	if err != nil {
+		return nil, err
+	}
+	return domain.KeywordTexts(kws[0]), nil
+}
+
+// ExtractCorpusKeywords returns the top keywords of each text, best first, favouring phrases
+// that are frequent in the text but rare in the other texts passed with it and in the other
+// documents of the space, as counted by stats
+func (e *Extractor) ExtractCorpusKeywords(ctx context.Context, texts []string, stats domain.TermStats) ([][]domain.Keyword, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cands := make([]map[string]*candidate, len(texts))
+	df := make(map[string]int)
+	for i, text := range texts {
+		cands[i] = scoreCandidates(text)
+		for key := range cands[i] {
+			df[key]++
+		}
+	}
+
+	// The texts form one more document of the space
+	docs := stats.Documents + 1
+	out := make([][]domain.Keyword, len(texts))
+	for i := range cands {
+		ranked := make([]*candidate, 0, len(cands[i]))
+		for key, c := range cands[i] {
+			c.score *= idf(len(texts), df[key]) * idf(docs, stats.DocumentFrequency[key]+1)
+			ranked = append(ranked, c)
+		}
+		sort.Slice(ranked, func(a, b int) bool {
+			if ranked[a].score != ranked[b].score {
+				return ranked[a].score > ranked[b].score
+			}
+			return ranked[a].first < ranked[b].first
+		})
+		kws := []domain.Keyword{}
+		for _, c := range ranked {
+			if len(kws) == e.maxKeywords {
+				break
+			}
+			kws = append(kws, domain.Keyword{Text: c.key, Score: c.score})
+		}
+		out[i] = kws
+	}
+	return out, nil
+}
+
+// Terms returns the distinct candidate phrases of texts, sorted
+func (e *Extractor) Terms(ctx context.Context, texts []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var terms []string
+	for _, text := range texts {
+		for _, words := range candidatePhrases(text) {
+			key := strings.Join(words, " ")
+			if !seen[key] {
+				seen[key] = true
+				terms = append(terms, key)
+			}
+		}
+	}
+	sort.Strings(terms)
+	return terms, nil
+}
+
+// idf is the smoothed inverse document frequency of a phrase found in df of n documents
+func idf(n, df int) float64 {
+	return math.Log(float64(1+n)/float64(1+df)) + 1
+}
+
+// candidate is a keyword phrase and its score within one text
+type candidate struct {
+	key   string
+	count int
+	first int // index of the first occurrence among the text's candidate phrases
+	score float64
+}
+
+// scoreCandidates finds the candidate phrases of text and scores them:
+// RAKE(p) = sum over words w of p of degree(w)/freq(w), weighted by 1+ln(count)
+// for repetition and by up to 1.5 for phrases that occur early in the text
+func scoreCandidates(text string) map[string]*candidate {
+	phrases := candidatePhrases(text)
+	freq := make(map[string]int)
+	degree := make(map[string]int)
+	for _, words := range phrases {
+		for _, w := range words {
+			freq[w]++
+			degree[w] += len(words)
+		}
+	}
+
+	cands := make(map[string]*candidate)
+	for i, words := range phrases {
+		key := strings.Join(words, " ")
+		c, ok := cands[key]
+		if !ok {
+			c = &candidate{key: key, first: i}
+			for _, w := range words {
+				c.score += float64(degree[w]) / float64(freq[w])
+			}
+			cands[key] = c
+		}
+		c.count++
+	}
+	for _, c := range cands {
+		c.score *= (1 + math.Log(float64(c.count))) * (1 + 0.5/(1+math.Log1p(float64(c.first))))
+	}
+	return cands
+}
+
+// candidatePhrases splits text into lower-cased phrases of content words
+func candidatePhrases(text string) [][]string {
+	var (
+		phrases [][]string
+		phrase  []string
+	)
+	flush := func() {
+		for len(phrase) > 0 {
+			n := min(len(phrase), maxPhraseWords)
+			phrases = append(phrases, phrase[:n])
+			phrase = phrase[n:]
+		}
+		phrase = nil
+	}
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isWordRune(r):
+			end := wordEnd(text, i)
+			word := strings.ToLower(text[i:end])
+			if isStopword(word) {
+				flush()
+			} else {
+				phrase = append(phrase, word)
+			}
+			i = end
+		case r == ' ' || r == '\t':
+			// Spaces separate words of one phrase; anything else ends it
+			i += size
+		default:
+			flush()
+			i += size
+		}
+	}
+	flush()
+	return phrases
+}
+
+// wordEnd returns the end of the word starting at i. Apostrophes and hyphens between
+// letters stay inside the word.
+func wordEnd(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if isWordRune(r) {
+			i += size
+			continue
+		}
+		if r == '\'' || r == '-' || r == '’' {
+			if next, _ := utf8.DecodeRuneInString(text[i+size:]); i+size < len(text) && unicode.IsLetter(next) && !unicode.Is(unicode.Hiragana, next) {
+				i += size
+				continue
+			}
+		}
+		break
+	}
+	return i
+}
+
+// isWordRune reports whether r can be part of a keyword. Hiragana is excluded: in Japanese it
+// mostly spells particles and inflections, so it delimits the kanji and katakana terms around it.
+func isWordRune(r rune) bool {
+	if unicode.Is(unicode.Hiragana, r) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// isStopword reports whether word carries no meaning of its own: a stopword, a number, or
+// a single character, which in Japanese is mostly a counter or a verb stem
+func isStopword(word string) bool {
+	if englishStopwords[word] {
+		return true
+	}
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters == 0 {
+		return true
+	}
+	return utf8.RuneCountInString(word) == 1
+}
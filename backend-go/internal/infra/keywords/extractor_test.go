@@ -0,0 +1,99 @@
+package keywords
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+func TestCandidatePhrases(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want [][]string
+	}{
+		{"stopwords split phrases", "The vector store keeps the embeddings of every chunk.", [][]string{{"vector", "store", "keeps"}, {"embeddings"}, {"chunk"}}},
+		{"punctuation and numbers", "Fast, cheap k-means in 2024", [][]string{{"fast"}, {"cheap", "k-means"}}},
+		{"long runs are capped", "alpha beta gamma delta epsilon", [][]string{{"alpha", "beta", "gamma"}, {"delta", "epsilon"}}},
+		{"japanese", "機械学習のモデルをデータセットで評価する。", [][]string{{"機械学習"}, {"モデル"}, {"データセット"}, {"評価"}}},
+		{"mixed script", "Go言語で書く", [][]string{{"go言語"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := candidatePhrases(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidatePhrases(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractKeywords(t *testing.T) {
+	e := NewExtractor(2)
+	text := "Vector databases index embeddings. A vector database answers similarity search queries. Search is fast."
+	got, err := e.ExtractKeywords(context.Background(), text)
+	if err != nil {
+		t.Fatalf("ExtractKeywords failed: %v", err)
+	}
+	want := []string{"vector databases index", "vector database answers"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractKeywords = %q, want %q", got, want)
+	}
+}
+
+func TestExtractCorpusKeywordsPrefersDistinctiveTerms(t *testing.T) {
+	texts := []string{
+		"Qdrant stores vectors. Qdrant filters payloads.",
+		"Qdrant clusters vectors with k-means.",
+		"Qdrant projects vectors with PCA.",
+	}
+	got, err := NewExtractor(1).ExtractCorpusKeywords(context.Background(), texts, domain.TermStats{})
+	if err != nil {
+		t.Fatalf("ExtractCorpusKeywords failed: %v", err)
+	}
+	for i, kws := range got {
+		if len(kws) != 1 || kws[0].Text == "qdrant" || kws[0].Text == "vectors" {
+			t.Errorf("text %d: expected a term specific to it, got %v", i, kws)
+		}
+	}
+}
+
+func TestExtractCorpusKeywordsWeighsSpaceFrequency(t *testing.T) {
+	texts := []string{"Qdrant indexes embeddings. Qdrant indexes embeddings. Payload filters narrow searches."}
+	e := NewExtractor(10)
+	score := func(stats domain.TermStats, term string) float64 {
+		t.Helper()
+		got, err := e.ExtractCorpusKeywords(context.Background(), texts, stats)
+		if err != nil {
+			t.Fatalf("ExtractCorpusKeywords failed: %v", err)
+		}
+		for _, kw := range got[0] {
+			if kw.Text == term {
+				return kw.Score
+			}
+		}
+		t.Fatalf("%q not among the keywords %v", term, got[0])
+		return 0
+	}
+	rare := domain.TermStats{Documents: 9}
+	common := domain.TermStats{Documents: 9, DocumentFrequency: map[string]int{"qdrant indexes embeddings": 9}}
+	if r, c := score(rare, "qdrant indexes embeddings"), score(common, "qdrant indexes embeddings"); c >= r {
+		t.Errorf("a term in every document of the space should score lower: %v when rare, %v when common", r, c)
+	}
+	got, _ := e.ExtractCorpusKeywords(context.Background(), texts, common)
+	if got[0][0].Text != "payload filters narrow" {
+		t.Errorf("the term rare in the space should rank first, got %v", got[0])
+	}
+}
+
+func TestExtractKeywordsJapanese(t *testing.T) {
+	text := "ベクトル検索は埋め込みを使う。ベクトル検索の精度はモデルで決まる。"
+	got, err := NewExtractor(1).ExtractKeywords(context.Background(), text)
+	if err != nil {
+		t.Fatalf("ExtractKeywords failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"ベクトル検索"}) {
+		t.Errorf("ExtractKeywords = %q, want the repeated compound", got)
+	}
+}
@@ -0,0 +1,27 @@
+package keywords
+
+// englishStopwords are function words that delimit candidate phrases
+var englishStopwords = toSet(
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an", "and", "any", "are", "as", "at",
+	"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+	"can", "could", "did", "do", "does", "doing", "down", "during",
+	"each", "either", "else", "even", "ever", "every", "few", "for", "from", "further",
+	"get", "gets", "got", "had", "has", "have", "having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how", "however",
+	"i", "if", "in", "into", "is", "it", "its", "itself", "just", "let", "like",
+	"many", "may", "me", "might", "more", "most", "much", "must", "my", "myself",
+	"neither", "no", "nor", "not", "now", "of", "off", "often", "on", "once", "one", "only", "or", "other", "our", "ours", "ourselves", "out", "over", "own",
+	"per", "rather", "same", "shall", "she", "should", "so", "some", "such",
+	"than", "that", "the", "their", "theirs", "them", "themselves", "then", "there", "these", "they", "this", "those", "through", "thus", "to", "too",
+	"under", "until", "up", "upon", "us", "use", "used", "uses", "using", "very", "via",
+	"was", "we", "well", "were", "what", "when", "where", "whether", "which", "while", "who", "whom", "whose", "why", "will", "with", "within", "without", "would",
+	"yet", "you", "your", "yours", "yourself", "yourselves",
+	"it's", "don't", "doesn't", "isn't", "aren't", "can't", "won't", "i'm", "we're", "they're", "you're",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
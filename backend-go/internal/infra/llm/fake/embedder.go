@@ -0,0 +1,77 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+// Embedder implements ports.EmbeddingModel without a model: each feature of a text (words, word
+// pairs and CJK character bigrams) is hashed into one of dim buckets with a hashed sign, weighted
+// by 1+ln(count), and the vector is L2-normalized. Texts sharing vocabulary therefore get a high
+// cosine similarity, and the same text always gets the same vector.
+type Embedder struct {
+	dim uint64
+}
+
+// NewEmbedder creates a hashing embedder producing vectors of dim dimensions
+func NewEmbedder(dim uint64) (*Embedder, error) {
+	if dim == 0 {
+		return nil, errors.New("embedding dimension must be > 0")
+	}
+	return &Embedder{dim: dim}, nil
+}
+
+// GenerateEmbedding embeds text; a text without any letter or digit gets the zero vector
+func (e *Embedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, f := range features(text) {
+		counts[f]++
+	}
+	acc := make([]float64, e.dim)
+	for f, n := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		weight := 1 + math.Log(float64(n))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		acc[sum%e.dim] += weight
+	}
+	var norm float64
+	for _, x := range acc {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	vector := make([]float32, e.dim)
+	if norm == 0 {
+		return vector, nil
+	}
+	for i, x := range acc {
+		vector[i] = float32(x / norm)
+	}
+	return vector, nil
+}
+
+// GenerateEmbeddings embeds each of texts
+func (e *Embedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, err := e.GenerateEmbedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// GetEmbeddingDimension returns the configured dimension
+func (e *Embedder) GetEmbeddingDimension() uint64 {
+	return e.dim
+}
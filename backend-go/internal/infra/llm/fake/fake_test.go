@@ -0,0 +1,126 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var _ ports.StreamingLLM = (*LLM)(nil)
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+func TestEmbedder(t *testing.T) {
+	e, err := NewEmbedder(256)
+	if err != nil {
+		t.Fatalf("NewEmbedder failed: %v", err)
+	}
+	ctx := context.Background()
+	texts := []string{
+		"The vector database stores document embeddings.",
+		"Document embeddings are stored in a vector database.",
+		"Bake the bread at high heat until golden.",
+		"ベクトルデータベースに文書を保存する。",
+		"文書をベクトルデータベースへ保存します。",
+	}
+	vectors, err := e.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	for i, v := range vectors {
+		if uint64(len(v)) != e.GetEmbeddingDimension() {
+			t.Fatalf("vector %d has %d dimensions, want 256", i, len(v))
+		}
+		if norm := cosine(v, v); math.Abs(norm-1) > 1e-5 {
+			t.Errorf("vector %d has squared norm %f, want 1", i, norm)
+		}
+	}
+	again, _ := e.GenerateEmbedding(ctx, texts[0])
+	if !reflect.DeepEqual(again, vectors[0]) {
+		t.Error("the same text should always get the same vector")
+	}
+	if related, unrelated := cosine(vectors[0], vectors[1]), cosine(vectors[0], vectors[2]); related <= unrelated {
+		t.Errorf("related texts scored %f, unrelated %f", related, unrelated)
+	}
+	if related, unrelated := cosine(vectors[3], vectors[4]), cosine(vectors[3], vectors[2]); related <= unrelated {
+		t.Errorf("related Japanese texts scored %f, unrelated %f", related, unrelated)
+	}
+
+	if _, err := NewEmbedder(0); err == nil {
+		t.Error("a zero dimension should be rejected")
+	}
+}
+
+func TestLLMSummary(t *testing.T) {
+	l := NewLLM()
+	ctx := context.Background()
+
+	short := "Qdrant stores vectors. It answers queries."
+	if got, _ := l.GenerateSummary(ctx, short); got != short {
+		t.Errorf("short text summary = %q, want the text itself", got)
+	}
+
+	text := "Vector search finds similar documents. " +
+		"The weather was pleasant yesterday. " +
+		"Similar documents are found by comparing vector embeddings. " +
+		"Embeddings let vector search compare documents by meaning. " +
+		"My cat sleeps all afternoon."
+	got, err := l.GenerateSummary(ctx, text)
+	if err != nil {
+		t.Fatalf("GenerateSummary failed: %v", err)
+	}
+	want := "Vector search finds similar documents. Similar documents are found by comparing vector embeddings. Embeddings let vector search compare documents by meaning."
+	if got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	// Abbreviations do not end a sentence
+	if got := splitSentences("Dr. Smith compares e.g. cosine and dot scores."); len(got) != 1 {
+		t.Errorf("abbreviations split the sentence: %q", got)
+	}
+	if _, err := l.GenerateSummary(ctx, "  "); !errors.Is(err, domain.ErrSummaryGeneration) {
+		t.Errorf("expected ErrSummaryGeneration for empty text, got %v", err)
+	}
+}
+
+func TestLLMAnswerQuestion(t *testing.T) {
+	l := NewLLM()
+	ctx := context.Background()
+	passages := []string{
+		"Go is a compiled language. It has goroutines.",
+		"Qdrant is a vector database. Collections hold points with payloads.",
+	}
+	got, err := l.AnswerQuestion(ctx, passages, "What is Qdrant?")
+	if err != nil {
+		t.Fatalf("AnswerQuestion failed: %v", err)
+	}
+	if got != "According to passage [2]: Qdrant is a vector database." {
+		t.Errorf("answer = %q", got)
+	}
+	stream, err := l.StreamAnswer(ctx, passages, "What is Qdrant?")
+	if err != nil {
+		t.Fatalf("StreamAnswer failed: %v", err)
+	}
+	var streamed strings.Builder
+	for chunk := range stream {
+		streamed.WriteString(chunk.Delta)
+	}
+	if streamed.String() != got {
+		t.Errorf("streamed answer %q differs from %q", streamed.String(), got)
+	}
+	if got, _ := l.AnswerQuestion(ctx, passages, "Who painted Guernica?"); !strings.Contains(got, "do not know") {
+		t.Errorf("unanswerable question got %q", got)
+	}
+}
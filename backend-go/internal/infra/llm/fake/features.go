@@ -0,0 +1,71 @@
+package fake
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ran/demo/backend-go/internal/text"
+)
+
+// features returns the terms that describe text for embedding and similarity: lower-cased words
+// and adjacent word pairs, plus character bigrams within runs of CJK characters, which are
+// written without spaces
+func features(text string) []string {
+	words := words(text)
+	feats := make([]string, 0, 2*len(words))
+	for i, w := range words {
+		runes := []rune(w)
+		if isCJK(runes[0]) {
+			if len(runes) == 1 {
+				feats = append(feats, w)
+			}
+			for j := 0; j+1 < len(runes); j++ {
+				feats = append(feats, string(runes[j:j+2]))
+			}
+			continue
+		}
+		feats = append(feats, w)
+		if i > 0 && !isCJK([]rune(words[i-1])[0]) {
+			feats = append(feats, words[i-1]+" "+w)
+		}
+	}
+	return feats
+}
+
+// words splits text into lower-cased runs of letters and digits, keeping CJK and other
+// scripts in separate runs
+func words(text string) []string {
+	var (
+		out  []string
+		curr []rune
+		cjk  bool
+	)
+	flush := func() {
+		if len(curr) > 0 {
+			out = append(out, string(curr))
+			curr = curr[:0]
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(curr) > 0 && isCJK(r) != cjk {
+			flush()
+		}
+		cjk = isCJK(r)
+		curr = append(curr, r)
+	}
+	flush()
+	return out
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || r == 'ー'
+}
+
+// splitSentences splits s into sentences with the shared, language-aware splitter
+func splitSentences(s string) []string {
+	return text.SplitSentences(s, text.DetectLanguage(s))
+}
@@ -0,0 +1,205 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/keywords"
+)
+
+// summarySentences is the number of sentences an extractive summary keeps
+const summarySentences = 3
+
+// textRank parameters
+const (
+	damping        = 0.85
+	rankIterations = 50
+)
+
+// LLM implements ports.LLM offline and deterministically: summaries are extracted with TextRank,
+// answers quote the context sentence that best matches the question, keywords come from the
+// local keyword extractor, and completions are a fixed template.
+type LLM struct {
+	keywords *keywords.Extractor
+}
+
+// NewLLM creates an offline LLM
+func NewLLM() *LLM {
+	return &LLM{keywords: keywords.NewExtractor(keywords.DefaultMaxKeywords)}
+}
+
+// GenerateCompletion returns a template reply naming the start of the prompt
+func (l *LLM) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	first := ""
+	if sentences := splitSentences(prompt); len(sentences) > 0 {
+		first = sentences[0]
+	}
+	return fmt.Sprintf("This is an offline reply generated without a language model. You wrote: %q", first), nil
+}
+
+// GenerateSummary returns the most central sentences of text in their original order, or the
+// whole text when it is no longer than the summary
+func (l *LLM) GenerateSummary(ctx context.Context, text string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	sentences := splitSentences(text)
+	if len(sentences) == 0 {
+		return "", domain.NewErrSummaryGeneration(errors.New("nothing to summarize"))
+	}
+	if len(sentences) <= summarySentences {
+		return joinSentences(sentences), nil
+	}
+	scores := textRank(sentences)
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+	// Ties go to the earlier sentence, so documents without a clear center fall back to a lead summary
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	picked := order[:summarySentences]
+	sort.Ints(picked)
+	out := make([]string, len(picked))
+	for i, idx := range picked {
+		out[i] = sentences[idx]
+	}
+	return joinSentences(out), nil
+}
+
+// AnswerQuestion quotes the context sentence sharing the most terms with the question
+func (l *LLM) AnswerQuestion(ctx context.Context, context []string, question string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	q := featureSet(question)
+	best, bestPassage, bestScore := "", 0, 0
+	for i, passage := range context {
+		for _, s := range splitSentences(passage) {
+			if score := overlap(q, featureSet(s)); score > bestScore {
+				best, bestPassage, bestScore = s, i+1, score
+			}
+		}
+	}
+	if bestScore == 0 {
+		return "I do not know: the context does not mention this.", nil
+	}
+	return fmt.Sprintf("According to passage [%d]: %s", bestPassage, best), nil
+}
+
+// StreamCompletion streams the GenerateCompletion reply word by word
+func (l *LLM) StreamCompletion(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	reply, err := l.GenerateCompletion(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return streamWords(ctx, reply), nil
+}
+
+// StreamAnswer streams the AnswerQuestion reply word by word
+func (l *LLM) StreamAnswer(ctx context.Context, context []string, question string) (<-chan domain.StreamChunk, error) {
+	reply, err := l.AnswerQuestion(ctx, context, question)
+	if err != nil {
+		return nil, err
+	}
+	return streamWords(ctx, reply), nil
+}
+
+// ExtractKeywords extracts keywords with the local TF-IDF/RAKE extractor
+func (l *LLM) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	return l.keywords.ExtractKeywords(ctx, text)
+}
+
+// streamWords emits text one word, with its trailing space, at a time
+func streamWords(ctx context.Context, text string) <-chan domain.StreamChunk {
+	out := make(chan domain.StreamChunk)
+	go func() {
+		defer close(out)
+		for _, word := range strings.SplitAfter(text, " ") {
+			select {
+			case out <- domain.StreamChunk{Delta: word}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// textRank scores sentences by PageRank over a graph weighted by shared terms, normalized by
+// sentence length as in the original TextRank
+func textRank(sentences []string) []float64 {
+	n := len(sentences)
+	sets := make([]map[string]bool, n)
+	for i, s := range sentences {
+		sets[i] = featureSet(s)
+	}
+	weights := make([][]float64, n)
+	outSum := make([]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+		for j := range weights[i] {
+			if i == j || len(sets[i]) == 0 || len(sets[j]) == 0 {
+				continue
+			}
+			w := float64(overlap(sets[i], sets[j])) / (math.Log(float64(len(sets[i])+1)) + math.Log(float64(len(sets[j])+1)))
+			weights[i][j] = w
+			outSum[i] += w
+		}
+	}
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1
+	}
+	for iter := 0; iter < rankIterations; iter++ {
+		next := make([]float64, n)
+		for j := range next {
+			sum := 0.0
+			for i := range scores {
+				if weights[i][j] > 0 {
+					sum += weights[i][j] / outSum[i] * scores[i]
+				}
+			}
+			next[j] = 1 - damping + damping*sum
+		}
+		scores = next
+	}
+	return scores
+}
+
+func featureSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, f := range features(text) {
+		set[f] = true
+	}
+	return set
+}
+
+func overlap(a, b map[string]bool) int {
+	n := 0
+	for f := range a {
+		if b[f] {
+			n++
+		}
+	}
+	return n
+}
+
+// joinSentences joins sentences with a space, except after CJK terminators
+func joinSentences(sentences []string) string {
+	var sb strings.Builder
+	for i, s := range sentences {
+		if i > 0 && !strings.HasSuffix(sentences[i-1], "。") && !strings.HasSuffix(sentences[i-1], "！") && !strings.HasSuffix(sentences[i-1], "？") {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package fallback
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a provider's circuit breaker
+type CircuitState string
+
+// Circuit breaker states
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects calls until the cooldown has passed.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single trial call through; its outcome closes or reopens the circuit.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// circuitBreaker stops calling a provider after threshold consecutive failures, then lets one
+// trial call through every cooldown until a call succeeds
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now, state: CircuitClosed}
+}
+
+// allow reports whether a call may go through. Once the cooldown has passed, the first caller
+// becomes the half-open trial and everyone else is still rejected.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		return true
+	default:
+		return false
+	}
+}
+
+// success closes the circuit
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = CircuitClosed
+	b.failures = 0
+}
+
+// failure counts a failed call, opening the circuit at the threshold or when the trial failed
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon releases a trial that ended without a verdict, e.g. because the caller gave up, so
+// the next call can try again
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitHalfOpen {
+		b.state = CircuitOpen
+	}
+}
+
+func (b *circuitBreaker) current() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,322 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// ErrCircuitOpen is returned when every provider able to serve a call has an open circuit
+var ErrCircuitOpen = errors.New("circuit breaker open for every provider")
+
+// Provider is one entry of a Chain. Either model may be nil when the provider only offers the other.
+type Provider struct {
+	Name     string
+	LLM      ports.LLM
+	Embedder ports.EmbeddingModel
+}
+
+// BreakerConfig tunes the per-provider circuit breakers. Zero values select the defaults.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that open a circuit (default 3).
+	FailureThreshold int
+	// Cooldown is how long an open circuit rejects calls before a trial call (default 30s).
+	Cooldown time.Duration
+}
+
+// ProviderStats reports the calls a provider served and failed, and its circuit states
+type ProviderStats struct {
+	Name             string
+	Served           int64
+	Failed           int64
+	LLMCircuit       CircuitState
+	EmbeddingCircuit CircuitState
+}
+
+type member struct {
+	Provider
+	llmBreaker   *circuitBreaker
+	embedBreaker *circuitBreaker
+	served       atomic.Int64
+	failed       atomic.Int64
+}
+
+// Chain implements ports.StreamingLLM and ports.EmbeddingModel over an ordered list of providers. Each
+// call goes to the first provider whose circuit is not open and falls through to the next one
+// on failure. Generation and embedding calls have separate circuits, as they hit different
+// endpoints.
+//
+// Every embedding provider must have the same dimension, as vectors of another dimension cannot
+// share a collection; NewChain rejects a chain that mixes them. Fallbacks of equal dimension
+// should serve the same model, or search quality degrades silently.
+type Chain struct {
+	members   []*member
+	embedders []*member
+	dim       uint64
+}
+
+// NewChain creates a Chain trying providers in order. It fails when the embedding providers
+// differ in dimension.
+func NewChain(providers []Provider, cfg BreakerConfig) (*Chain, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("at least one provider is required")
+	}
+	if cfg.FailureThreshold < 0 || cfg.Cooldown < 0 {
+		return nil, errors.New("circuit breaker settings must not be negative")
+	}
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = 3
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	c := &Chain{}
+	names := make(map[string]bool)
+	for _, p := range providers {
+		if p.Name == "" || names[p.Name] {
+			return nil, fmt.Errorf("provider names must be unique and non-empty, got %q", p.Name)
+		}
+		if p.LLM == nil && p.Embedder == nil {
+			return nil, fmt.Errorf("provider %q has neither an LLM nor an embedding model", p.Name)
+		}
+		names[p.Name] = true
+		m := &member{
+			Provider:     p,
+			llmBreaker:   newCircuitBreaker(cfg.FailureThreshold, cfg.Cooldown),
+			embedBreaker: newCircuitBreaker(cfg.FailureThreshold, cfg.Cooldown),
+		}
+		c.members = append(c.members, m)
+		if p.Embedder == nil {
+			continue
+		}
+		dim := p.Embedder.GetEmbeddingDimension()
+		if len(c.embedders) == 0 {
+			c.dim = dim
+		} else if dim != c.dim {
+			return nil, fmt.Errorf("provider %q embeds with dimension %d, but %q with %d", p.Name, dim, c.embedders[0].Name, c.dim)
+		}
+		c.embedders = append(c.embedders, m)
+	}
+	return c, nil
+}
+
+// GenerateCompletion generates a completion with the first available provider
+func (c *Chain) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	return callLLM(ctx, c, "GenerateCompletion", func(llm ports.LLM) (string, error) {
+		return llm.GenerateCompletion(ctx, prompt)
+	})
+}
+
+// GenerateSummary generates a summary with the first available provider
+func (c *Chain) GenerateSummary(ctx context.Context, text string) (string, error) {
+	return callLLM(ctx, c, "GenerateSummary", func(llm ports.LLM) (string, error) {
+		return llm.GenerateSummary(ctx, text)
+	})
+}
+
+// AnswerQuestion answers a question with the first available provider
+func (c *Chain) AnswerQuestion(ctx context.Context, context []string, question string) (string, error) {
+	return callLLM(ctx, c, "AnswerQuestion", func(llm ports.LLM) (string, error) {
+		return llm.AnswerQuestion(ctx, context, question)
+	})
+}
+
+// ExtractKeywords extracts keywords with the first available provider
+func (c *Chain) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	return callLLM(ctx, c, "ExtractKeywords", func(llm ports.LLM) ([]string, error) {
+		return llm.ExtractKeywords(ctx, text)
+	})
+}
+
+// StreamCompletion streams a completion from the first provider that starts one. Providers
+// without streaming support reply in a single chunk. Once a stream has started, a failure is
+// reported in the stream and does not fall back.
+func (c *Chain) StreamCompletion(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	return callLLM(ctx, c, "StreamCompletion", func(llm ports.LLM) (<-chan domain.StreamChunk, error) {
+		if s, ok := llm.(ports.StreamingLLM); ok {
+			return s.StreamCompletion(ctx, prompt)
+		}
+		return single(llm.GenerateCompletion(ctx, prompt))
+	})
+}
+
+// StreamAnswer streams an answer from the first provider that starts one, as StreamCompletion does
+func (c *Chain) StreamAnswer(ctx context.Context, context []string, question string) (<-chan domain.StreamChunk, error) {
+	return callLLM(ctx, c, "StreamAnswer", func(llm ports.LLM) (<-chan domain.StreamChunk, error) {
+		if s, ok := llm.(ports.StreamingLLM); ok {
+			return s.StreamAnswer(ctx, context, question)
+		}
+		return single(llm.AnswerQuestion(ctx, context, question))
+	})
+}
+
+// single turns a whole reply into a stream of one chunk
+func single(reply string, err error) (<-chan domain.StreamChunk, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan domain.StreamChunk, 1)
+	out <- domain.StreamChunk{Delta: reply}
+	close(out)
+	return out, nil
+}
+
+// GenerateEmbedding embeds text with the first available provider of the chain's dimension
+func (c *Chain) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return callEmbedder(ctx, c, "GenerateEmbedding", func(e ports.EmbeddingModel) ([]float32, error) {
+		return e.GenerateEmbedding(ctx, text)
+	})
+}
+
+// GenerateEmbeddings embeds texts with the first available provider of the chain's dimension.
+// A batch is always embedded by a single provider.
+func (c *Chain) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	return callEmbedder(ctx, c, "GenerateEmbeddings", func(e ports.EmbeddingModel) ([][]float32, error) {
+		return e.GenerateEmbeddings(ctx, texts)
+	})
+}
+
+// GetEmbeddingDimension returns the dimension of the first embedding provider
+func (c *Chain) GetEmbeddingDimension() uint64 {
+	return c.dim
+}
+
+// Stats returns the statistics of every provider, in chain order
+func (c *Chain) Stats() []ProviderStats {
+	out := make([]ProviderStats, len(c.members))
+	for i, m := range c.members {
+		out[i] = ProviderStats{
+			Name:             m.Name,
+			Served:           m.served.Load(),
+			Failed:           m.failed.Load(),
+			LLMCircuit:       m.llmBreaker.current(),
+			EmbeddingCircuit: m.embedBreaker.current(),
+		}
+	}
+	return out
+}
+
+func callLLM[T any](ctx context.Context, c *Chain, op string, fn func(ports.LLM) (T, error)) (T, error) {
+	var candidates []*member
+	for _, m := range c.members {
+		if m.LLM != nil {
+			candidates = append(candidates, m)
+		}
+	}
+	return call(ctx, candidates, op, func(m *member) *circuitBreaker { return m.llmBreaker }, func(m *member) (T, error) {
+		return fn(m.LLM)
+	})
+}
+
+func callEmbedder[T any](ctx context.Context, c *Chain, op string, fn func(ports.EmbeddingModel) (T, error)) (T, error) {
+	return call(ctx, c.embedders, op, func(m *member) *circuitBreaker { return m.embedBreaker }, func(m *member) (T, error) {
+		return fn(m.Embedder)
+	})
+}
+
+// call tries candidates in order, skipping open circuits, and records the provider that served it.
+// A call cancelled by its context neither counts against the provider nor falls through.
+func call[T any](ctx context.Context, candidates []*member, op string, breaker func(*member) *circuitBreaker, fn func(*member) (T, error)) (T, error) {
+	var zero T
+	if len(candidates) == 0 {
+		return zero, fmt.Errorf("no provider supports %s", op)
+	}
+	var errs []error
+	for _, m := range candidates {
+		b := breaker(m)
+		if !b.allow() {
+			continue
+		}
+		out, err := fn(m)
+		if err == nil {
+			b.success()
+			m.served.Add(1)
+			recordServed(ctx, op, m.Name)
+			return out, nil
+		}
+		if ctx.Err() != nil {
+			b.abandon()
+			return zero, err
+		}
+		b.failure()
+		m.failed.Add(1)
+		errs = append(errs, fmt.Errorf("provider %s: %w", m.Name, err))
+	}
+	if len(errs) == 0 {
+		return zero, ErrCircuitOpen
+	}
+	return zero, fmt.Errorf("all providers failed %s: %w", op, errors.Join(errs...))
+}
+
+// Call is a call served by a Chain: the method name and the provider that answered
+type Call struct {
+	Op       string
+	Provider string
+}
+
+// Served collects the calls served under a context returned by WithServed
+type Served struct {
+	mu     sync.Mutex
+	calls  []Call
+	parent *Served
+}
+
+type servedKey struct{}
+
+// WithServed returns a context under which every Chain call records the provider that served it.
+// Calls are also recorded by any Served of an enclosing context.
+func WithServed(ctx context.Context) (context.Context, *Served) {
+	parent, _ := ctx.Value(servedKey{}).(*Served)
+	s := &Served{parent: parent}
+	return context.WithValue(ctx, servedKey{}, s), s
+}
+
+// ServedByPrimary returns a context recording the calls made under it, and a function reporting
+// whether every call so far was served by the first embedding provider. A cache keyed by the
+// primary's model uses it to leave out vectors a fallback embedded with another model.
+func (c *Chain) ServedByPrimary(ctx context.Context) (context.Context, func() bool) {
+	ctx, served := WithServed(ctx)
+	return ctx, func() bool {
+		for _, call := range served.Calls() {
+			if len(c.embedders) == 0 || call.Provider != c.embedders[0].Name {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Calls returns the calls recorded so far, in completion order
+func (s *Served) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// Providers returns the distinct providers that served calls, in order of first use
+func (s *Served) Providers() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, c := range s.Calls() {
+		if !seen[c.Provider] {
+			seen[c.Provider] = true
+			out = append(out, c.Provider)
+		}
+	}
+	return out
+}
+
+func recordServed(ctx context.Context, op, provider string) {
+	s, _ := ctx.Value(servedKey{}).(*Served)
+	for ; s != nil; s = s.parent {
+		s.mu.Lock()
+		s.calls = append(s.calls, Call{Op: op, Provider: provider})
+		s.mu.Unlock()
+	}
+}
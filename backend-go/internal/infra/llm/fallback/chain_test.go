@@ -0,0 +1,243 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var (
+	_ ports.StreamingLLM   = (*Chain)(nil)
+	_ ports.EmbeddingModel = (*Chain)(nil)
+)
+
+// stubProvider answers with its name, or fails with err while err is set
+type stubProvider struct {
+	name  string
+	dim   uint64
+	err   error
+	calls int
+}
+
+func (s *stubProvider) reply(ctx context.Context) (string, error) {
+	s.calls++
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.name, nil
+}
+
+func (s *stubProvider) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	return s.reply(ctx)
+}
+
+func (s *stubProvider) GenerateSummary(ctx context.Context, text string) (string, error) {
+	return s.reply(ctx)
+}
+
+func (s *stubProvider) AnswerQuestion(ctx context.Context, context []string, question string) (string, error) {
+	return s.reply(ctx)
+}
+
+func (s *stubProvider) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	out, err := s.reply(ctx)
+	return []string{out}, err
+}
+
+func (s *stubProvider) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if _, err := s.reply(ctx); err != nil {
+		return nil, err
+	}
+	return make([]float32, s.dim), nil
+}
+
+func (s *stubProvider) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	v, err := s.GenerateEmbedding(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return [][]float32{v}, nil
+}
+
+func (s *stubProvider) GetEmbeddingDimension() uint64 { return s.dim }
+
+func newTestChain(t *testing.T, stubs ...*stubProvider) *Chain {
+	t.Helper()
+	providers := make([]Provider, len(stubs))
+	for i, s := range stubs {
+		providers[i] = Provider{Name: s.name, LLM: s, Embedder: s}
+	}
+	c, err := NewChain(providers, BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+	return c
+}
+
+var errDown = errors.New("provider down")
+
+func TestChainFallsBackAndRecordsProvider(t *testing.T) {
+	primary := &stubProvider{name: "primary", dim: 4, err: errDown}
+	backup := &stubProvider{name: "backup", dim: 4}
+	c := newTestChain(t, primary, backup)
+
+	ctx, served := WithServed(context.Background())
+	got, err := c.GenerateSummary(ctx, "text")
+	if err != nil || got != "backup" {
+		t.Fatalf("GenerateSummary = %q, %v; want the backup's reply", got, err)
+	}
+	primary.err = nil
+	if _, err := c.GenerateCompletion(ctx, "prompt"); err != nil {
+		t.Fatalf("GenerateCompletion failed: %v", err)
+	}
+	want := []Call{{"GenerateSummary", "backup"}, {"GenerateCompletion", "primary"}}
+	if !reflect.DeepEqual(served.Calls(), want) {
+		t.Errorf("served calls = %v, want %v", served.Calls(), want)
+	}
+	if got := served.Providers(); !reflect.DeepEqual(got, []string{"backup", "primary"}) {
+		t.Errorf("providers = %v", got)
+	}
+}
+
+func TestChainServedByPrimary(t *testing.T) {
+	primary := &stubProvider{name: "primary", dim: 4, err: errDown}
+	backup := &stubProvider{name: "backup", dim: 4}
+	c := newTestChain(t, primary, backup)
+
+	outer, served := WithServed(context.Background())
+	ctx, fromPrimary := c.ServedByPrimary(outer)
+	if _, err := c.GenerateEmbedding(ctx, "text"); err != nil {
+		t.Fatalf("GenerateEmbedding failed: %v", err)
+	}
+	if fromPrimary() {
+		t.Error("embedding served by the backup reported as served by the primary")
+	}
+	if got := served.Providers(); !reflect.DeepEqual(got, []string{"backup"}) {
+		t.Errorf("enclosing recorder got providers %v, want [backup]", got)
+	}
+
+	primary.err = nil
+	ctx, fromPrimary = c.ServedByPrimary(context.Background())
+	if _, err := c.GenerateEmbedding(ctx, "text"); err != nil {
+		t.Fatalf("GenerateEmbedding failed: %v", err)
+	}
+	if !fromPrimary() {
+		t.Error("embedding served by the primary not reported as such")
+	}
+}
+
+func TestChainCircuitBreaker(t *testing.T) {
+	primary := &stubProvider{name: "primary", dim: 4, err: errDown}
+	backup := &stubProvider{name: "backup", dim: 4}
+	c := newTestChain(t, primary, backup)
+	now := time.Unix(0, 0)
+	c.members[0].llmBreaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if _, err := c.GenerateCompletion(ctx, "prompt"); err != nil {
+			t.Fatalf("GenerateCompletion failed: %v", err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary called %d times, the circuit should open after 2 failures", primary.calls)
+	}
+	if stats := c.Stats()[0]; stats.LLMCircuit != CircuitOpen || stats.EmbeddingCircuit != CircuitClosed || stats.Failed != 2 {
+		t.Errorf("primary stats = %+v", stats)
+	}
+
+	// After the cooldown a single trial goes through; its failure reopens the circuit at once
+	now = now.Add(time.Minute)
+	c.GenerateCompletion(ctx, "prompt")
+	c.GenerateCompletion(ctx, "prompt")
+	if primary.calls != 3 {
+		t.Errorf("primary called %d times, want one trial call", primary.calls)
+	}
+
+	now = now.Add(time.Minute)
+	primary.err = nil
+	if got, _ := c.GenerateCompletion(ctx, "prompt"); got != "primary" {
+		t.Errorf("successful trial should serve the call, got %q", got)
+	}
+	if state := c.Stats()[0].LLMCircuit; state != CircuitClosed {
+		t.Errorf("circuit is %s after a successful trial, want closed", state)
+	}
+}
+
+func TestChainStreamFallsBack(t *testing.T) {
+	primary := &stubProvider{name: "primary", dim: 4, err: errDown}
+	backup := &stubProvider{name: "backup", dim: 4}
+	c := newTestChain(t, primary, backup)
+
+	ctx, served := WithServed(context.Background())
+	stream, err := c.StreamAnswer(ctx, []string{"passage"}, "question")
+	if err != nil {
+		t.Fatalf("StreamAnswer failed: %v", err)
+	}
+	var chunks []string
+	for chunk := range stream {
+		chunks = append(chunks, chunk.Delta)
+	}
+	if !reflect.DeepEqual(chunks, []string{"backup"}) || !reflect.DeepEqual(served.Providers(), []string{"backup"}) {
+		t.Errorf("stream = %q served by %v, want the backup's whole reply", chunks, served.Providers())
+	}
+}
+
+func TestChainEmbeddingsKeepDimension(t *testing.T) {
+	primary := &stubProvider{name: "primary", dim: 4, err: errDown}
+	wrongDim := &stubProvider{name: "small", dim: 2}
+	_, err := NewChain([]Provider{
+		{Name: primary.name, LLM: primary, Embedder: primary},
+		{Name: wrongDim.name, LLM: wrongDim, Embedder: wrongDim},
+	}, BreakerConfig{})
+	if err == nil {
+		t.Fatal("expected an error for embedders of different dimensions")
+	}
+
+	// A provider without an embedder may still back up generation
+	c, err := NewChain([]Provider{
+		{Name: primary.name, LLM: primary, Embedder: primary},
+		{Name: wrongDim.name, LLM: wrongDim},
+	}, BreakerConfig{})
+	if err != nil {
+		t.Fatalf("NewChain failed: %v", err)
+	}
+	if c.GetEmbeddingDimension() != 4 {
+		t.Errorf("dimension = %d, want the primary's 4", c.GetEmbeddingDimension())
+	}
+	if _, err := c.GenerateEmbeddings(context.Background(), []string{"text"}); !errors.Is(err, errDown) || wrongDim.calls != 0 {
+		t.Errorf("embedding fell back to a provider without an embedder: %v", err)
+	}
+	if got, _ := c.GenerateSummary(context.Background(), "text"); got != "small" {
+		t.Errorf("GenerateSummary served by %q, want small", got)
+	}
+}
+
+func TestChainErrors(t *testing.T) {
+	primary := &stubProvider{name: "primary", dim: 4, err: errDown}
+	backup := &stubProvider{name: "backup", dim: 4, err: errDown}
+	c := newTestChain(t, primary, backup)
+
+	_, err := c.AnswerQuestion(context.Background(), nil, "question")
+	if !errors.Is(err, errDown) {
+		t.Errorf("error should wrap the provider errors, got %v", err)
+	}
+	c.AnswerQuestion(context.Background(), nil, "question")
+	if _, err := c.AnswerQuestion(context.Background(), nil, "question"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen once every circuit is open, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GenerateEmbedding(ctx, "text")
+	if !errors.Is(err, context.Canceled) || backup.calls != 2 || c.Stats()[0].EmbeddingCircuit != CircuitClosed {
+		t.Errorf("a cancelled call should neither fall back nor count as a failure: %v", err)
+	}
+}
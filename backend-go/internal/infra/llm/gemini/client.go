@@ -0,0 +1,227 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/llm/httpapi"
+	"github.com/ran/demo/backend-go/internal/infra/llm/prompts"
+	"github.com/ran/demo/backend-go/internal/infra/llm/sse"
+)
+
+// maxBatchEmbedSize is the largest number of texts Gemini accepts in one batchEmbedContents call
+const maxBatchEmbedSize = 100
+
+// Config holds the settings of a GeminiClient
+type Config struct {
+	APIKey          string
+	BaseURL         string
+	GenerationModel string
+	EmbeddingModel  string
+	EmbeddingDim    uint64
+	Timeout         time.Duration
+}
+
+// GeminiClient implements ports.LLM and ports.EmbeddingModel over the Gemini REST API
+type GeminiClient struct {
+	cfg Config
+	api *httpapi.Client
+}
+
+// NewGeminiClient creates a new Gemini REST client
+func NewGeminiClient(cfg Config) (*GeminiClient, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("gemini api key is required")
+	}
+	if cfg.BaseURL == "" {
+		return nil, errors.New("gemini base url is required")
+	}
+	if cfg.GenerationModel == "" || cfg.EmbeddingModel == "" {
+		return nil, errors.New("gemini generation and embedding models are required")
+	}
+	if cfg.EmbeddingDim == 0 {
+		return nil, errors.New("gemini embedding dimension must be > 0")
+	}
+	cfg.GenerationModel = withModelsPrefix(cfg.GenerationModel)
+	cfg.EmbeddingModel = withModelsPrefix(cfg.EmbeddingModel)
+	return &GeminiClient{
+		cfg: cfg,
+		api: httpapi.New(httpapi.Config{
+			Name:     "gemini",
+			BaseURL:  strings.TrimRight(cfg.BaseURL, "/") + "/v1beta",
+			Header:   http.Header{"X-Goog-Api-Key": {cfg.APIKey}},
+			Timeout:  cfg.Timeout,
+			APIError: newAPIError,
+		}),
+	}, nil
+}
+
+// GenerateCompletion generates a completion for the given prompt
+func (g *GeminiClient) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	return g.generate(ctx, prompt)
+}
+
+// GenerateSummary generates a concise summary for the given text
+func (g *GeminiClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	summary, err := g.generate(ctx, prompts.Summary(text))
+	if err != nil {
+		return "", domain.NewErrSummaryGeneration(err)
+	}
+	return summary, nil
+}
+
+// AnswerQuestion answers a question using the given context passages
+func (g *GeminiClient) AnswerQuestion(ctx context.Context, context []string, question string) (string, error) {
+	return g.generate(ctx, prompts.Answer(context, question))
+}
+
+// StreamCompletion streams a completion for the given prompt
+func (g *GeminiClient) StreamCompletion(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	return g.stream(ctx, prompt)
+}
+
+// StreamAnswer streams the answer to a question using the given context passages
+func (g *GeminiClient) StreamAnswer(ctx context.Context, context []string, question string) (<-chan domain.StreamChunk, error) {
+	return g.stream(ctx, prompts.Answer(context, question))
+}
+
+// ExtractKeywords extracts keywords from the given text
+func (g *GeminiClient) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	out, err := g.generate(ctx, prompts.Keywords(text))
+	if err != nil {
+		return nil, err
+	}
+	return prompts.ParseKeywords(out), nil
+}
+
+// GenerateEmbedding generates a vector embedding for the given text
+func (g *GeminiClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	req := embedContentRequest{
+		Model:   g.cfg.EmbeddingModel,
+		Content: content{Parts: []part{{Text: text}}},
+	}
+	var resp embedContentResponse
+	if err := g.api.Post(ctx, "/"+g.cfg.EmbeddingModel+":embedContent", req, &resp); err != nil {
+		return nil, domain.NewErrEmbeddingGeneration(err)
+	}
+	if err := httpapi.CheckDimension(resp.Embedding.Values, g.cfg.EmbeddingDim); err != nil {
+		return nil, domain.NewErrEmbeddingGeneration(err)
+	}
+	return resp.Embedding.Values, nil
+}
+
+// GenerateEmbeddings generates embeddings for a batch of texts, splitting it to fit the API batch limit
+func (g *GeminiClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatchEmbedSize {
+		end := min(start+maxBatchEmbedSize, len(texts))
+		req := batchEmbedContentsRequest{Requests: make([]embedContentRequest, 0, end-start)}
+		for _, text := range texts[start:end] {
+			req.Requests = append(req.Requests, embedContentRequest{
+				Model:   g.cfg.EmbeddingModel,
+				Content: content{Parts: []part{{Text: text}}},
+			})
+		}
+		var resp batchEmbedContentsResponse
+		if err := g.api.Post(ctx, "/"+g.cfg.EmbeddingModel+":batchEmbedContents", req, &resp); err != nil {
+			return nil, domain.NewErrEmbeddingGeneration(err)
+		}
+		if len(resp.Embeddings) != end-start {
+			return nil, domain.NewErrEmbeddingGeneration(fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Embeddings)))
+		}
+		for _, e := range resp.Embeddings {
+			if err := httpapi.CheckDimension(e.Values, g.cfg.EmbeddingDim); err != nil {
+				return nil, domain.NewErrEmbeddingGeneration(err)
+			}
+			vectors = append(vectors, e.Values)
+		}
+	}
+	return vectors, nil
+}
+
+// GetEmbeddingDimension returns the configured embedding dimension
+func (g *GeminiClient) GetEmbeddingDimension() uint64 {
+	return g.cfg.EmbeddingDim
+}
+
+func generateRequest(prompt string) generateContentRequest {
+	return generateContentRequest{
+		Contents:         []content{{Role: "user", Parts: []part{{Text: prompt}}}},
+		GenerationConfig: &generationConfig{Temperature: 0.2},
+	}
+}
+
+func (g *GeminiClient) generate(ctx context.Context, prompt string) (string, error) {
+	var resp generateContentResponse
+	if err := g.api.Post(ctx, "/"+g.cfg.GenerationModel+":generateContent", generateRequest(prompt), &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Candidates) == 0 {
+		return "", errors.New("gemini returned no candidates")
+	}
+	var sb strings.Builder
+	for _, p := range resp.Candidates[0].Content.Parts {
+		sb.WriteString(p.Text)
+	}
+	text := strings.TrimSpace(sb.String())
+	if text == "" {
+		return "", fmt.Errorf("gemini returned empty content (finish reason %q)", resp.Candidates[0].FinishReason)
+	}
+	return text, nil
+}
+
+// stream starts a streamGenerateContent call and relays the text of each event. Errors before
+// the first event, such as a rejected request, are returned directly.
+func (g *GeminiClient) stream(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	resp, err := g.api.Stream(ctx, "/"+g.cfg.GenerationModel+":streamGenerateContent?alt=sse", generateRequest(prompt))
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan domain.StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		err := sse.Read(resp.Body, func(data string) error {
+			var event generateContentResponse
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return fmt.Errorf("failed to decode gemini stream event: %w", err)
+			}
+			if len(event.Candidates) == 0 {
+				return nil
+			}
+			var sb strings.Builder
+			for _, p := range event.Candidates[0].Content.Parts {
+				sb.WriteString(p.Text)
+			}
+			if sb.Len() == 0 {
+				return nil
+			}
+			select {
+			case out <- domain.StreamChunk{Delta: sb.String()}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			select {
+			case out <- domain.StreamChunk{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+func withModelsPrefix(model string) string {
+	if strings.HasPrefix(model, "models/") {
+		return model
+	}
+	return "models/" + model
+}
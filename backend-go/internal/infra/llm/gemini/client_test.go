@@ -0,0 +1,219 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var (
+	_ ports.StreamingLLM   = (*GeminiClient)(nil)
+	_ ports.EmbeddingModel = (*GeminiClient)(nil)
+)
+
+const testDim = 3
+
+// newStandIn starts a server emulating the Gemini endpoints used by GeminiClient
+func newStandIn(t *testing.T, reply string) (*GeminiClient, *int) {
+	t.Helper()
+	batchCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":401,"message":"bad key","status":"UNAUTHENTICATED"}}`))
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":generateContent"):
+			var req generateContentRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(generateContentResponse{Candidates: []candidate{{
+				Content: content{Parts: []part{{Text: reply}}},
+			}}})
+		case strings.HasSuffix(r.URL.Path, ":streamGenerateContent") && r.URL.Query().Get("alt") == "sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, word := range strings.SplitAfter(reply, " ") {
+				data, _ := json.Marshal(generateContentResponse{Candidates: []candidate{{
+					Content: content{Parts: []part{{Text: word}}},
+				}}})
+				fmt.Fprintf(w, "data: %s\r\n\r\n", data)
+			}
+		case strings.HasSuffix(r.URL.Path, ":embedContent"):
+			json.NewEncoder(w).Encode(embedContentResponse{Embedding: embedding{Values: []float32{1, 2, 3}}})
+		case strings.HasSuffix(r.URL.Path, ":batchEmbedContents"):
+			batchCalls++
+			var req batchEmbedContentsRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			resp := batchEmbedContentsResponse{}
+			for _, rq := range req.Requests {
+				n := float32(len(rq.Content.Parts[0].Text))
+				resp.Embeddings = append(resp.Embeddings, embedding{Values: []float32{n, 0, 0}})
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewGeminiClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         srv.URL,
+		GenerationModel: "gemini-test",
+		EmbeddingModel:  "models/embedding-test",
+		EmbeddingDim:    testDim,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client, &batchCalls
+}
+
+// withConfig creates a client of the same server with its configuration changed by fn
+func withConfig(t *testing.T, client *GeminiClient, fn func(*Config)) *GeminiClient {
+	t.Helper()
+	cfg := client.cfg
+	fn(&cfg)
+	changed, err := NewGeminiClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return changed
+}
+
+func TestGeminiGenerate(t *testing.T) {
+	client, _ := newStandIn(t, " Go, testing, 検索 , go ")
+	ctx := context.Background()
+
+	summary, err := client.GenerateSummary(ctx, "text")
+	if err != nil || summary != "Go, testing, 検索 , go" {
+		t.Errorf("GenerateSummary = %q, %v", summary, err)
+	}
+	keywords, err := client.ExtractKeywords(ctx, "text")
+	if err != nil {
+		t.Fatalf("ExtractKeywords failed: %v", err)
+	}
+	if strings.Join(keywords, "|") != "Go|testing|検索" {
+		t.Errorf("keywords = %v", keywords)
+	}
+}
+
+func TestGeminiStream(t *testing.T) {
+	client, _ := newStandIn(t, "Qdrant is a vector database.")
+	stream, err := client.StreamAnswer(context.Background(), []string{"Qdrant is a vector database."}, "What is Qdrant?")
+	if err != nil {
+		t.Fatalf("StreamAnswer failed: %v", err)
+	}
+	var deltas []string
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream failed: %v", chunk.Err)
+		}
+		deltas = append(deltas, chunk.Delta)
+	}
+	if len(deltas) != 5 || strings.Join(deltas, "") != "Qdrant is a vector database." {
+		t.Errorf("deltas = %q", deltas)
+	}
+
+	client = withConfig(t, client, func(cfg *Config) { cfg.APIKey = "wrong" })
+	var apiErr *APIError
+	if _, err := client.StreamCompletion(context.Background(), "prompt"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("a rejected stream should fail before it starts, got %v", err)
+	}
+}
+
+func TestGeminiEmbeddingsBatching(t *testing.T) {
+	client, batchCalls := newStandIn(t, "")
+	texts := make([]string, maxBatchEmbedSize+5)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+
+	vectors, err := client.GenerateEmbeddings(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	if *batchCalls != 2 {
+		t.Errorf("batch calls = %d, want 2", *batchCalls)
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i+1) {
+			t.Fatalf("vector %d out of order: %v", i, v)
+		}
+	}
+}
+
+func TestGeminiErrors(t *testing.T) {
+	client, _ := newStandIn(t, "")
+	ctx := context.Background()
+
+	client = withConfig(t, client, func(cfg *Config) { cfg.APIKey = "wrong" })
+	_, err := client.GenerateSummary(ctx, "text")
+	var apiErr *APIError
+	if !errors.Is(err, domain.ErrSummaryGeneration) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected summary error: %v", err)
+	}
+	_, err = client.GenerateEmbedding(ctx, "text")
+	if !errors.Is(err, domain.ErrEmbeddingGeneration) {
+		t.Errorf("unexpected embedding error: %v", err)
+	}
+
+	client = withConfig(t, client, func(cfg *Config) {
+		cfg.APIKey = "test-key"
+		cfg.EmbeddingDim = 4
+	})
+	_, err = client.GenerateEmbedding(ctx, "text")
+	if !errors.Is(err, domain.ErrEmbeddingGeneration) || !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("expected dimension error, got %v", err)
+	}
+}
+
+func TestGeminiStreamOutlivesTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range []string{"slow ", "reply"} {
+			data, _ := json.Marshal(generateContentResponse{Candidates: []candidate{{
+				Content: content{Parts: []part{{Text: word}}},
+			}}})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+			time.Sleep(150 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	client, err := NewGeminiClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         srv.URL,
+		GenerationModel: "gemini-test",
+		EmbeddingModel:  "embedding-test",
+		EmbeddingDim:    testDim,
+		Timeout:         50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	stream, err := client.StreamCompletion(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("StreamCompletion failed: %v", err)
+	}
+	var reply strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("a stream longer than the request timeout was cut off: %v", chunk.Err)
+		}
+		reply.WriteString(chunk.Delta)
+	}
+	if reply.String() != "slow reply" {
+		t.Errorf("reply = %q", reply.String())
+	}
+}
@@ -0,0 +1,93 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Wire types for the Gemini REST API (v1beta)
+
+type part struct {
+	Text string `json:"text"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type generationConfig struct {
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	Temperature     float64 `json:"temperature"`
+}
+
+type generateContentRequest struct {
+	Contents         []content         `json:"contents"`
+	GenerationConfig *generationConfig `json:"generationConfig,omitempty"`
+}
+
+type candidate struct {
+	Content      content `json:"content"`
+	FinishReason string  `json:"finishReason"`
+}
+
+type generateContentResponse struct {
+	Candidates []candidate `json:"candidates"`
+}
+
+type embedContentRequest struct {
+	Model   string  `json:"model"`
+	Content content `json:"content"`
+}
+
+type embedding struct {
+	Values []float32 `json:"values"`
+}
+
+type embedContentResponse struct {
+	Embedding embedding `json:"embedding"`
+}
+
+type batchEmbedContentsRequest struct {
+	Requests []embedContentRequest `json:"requests"`
+}
+
+type batchEmbedContentsResponse struct {
+	Embeddings []embedding `json:"embeddings"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// APIError is returned when the Gemini API responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gemini api error %d %s: %s", e.StatusCode, e.Status, e.Message)
+}
+
+// HTTPStatus implements domain.HTTPStatusError
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// newAPIError builds the error of a non-2xx response, preferring the message of a JSON error body
+func newAPIError(statusCode int, body []byte) error {
+	apiErr := &APIError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+	var errResp errorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+		apiErr.Status = errResp.Error.Status
+		apiErr.Message = errResp.Error.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,124 @@
+// Package httpapi sends JSON requests to the REST APIs of LLM providers, plainly or as a stream
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// defaultTimeout bounds a call, or the wait for the headers of a stream, when Config.Timeout is zero
+const defaultTimeout = 60 * time.Second
+
+// Config holds the settings of a Client
+type Config struct {
+	// Name identifies the provider in error messages, e.g. "gemini".
+	Name string
+	// BaseURL is prepended to the path of every request.
+	BaseURL string
+	// Header is added to every request, e.g. for authentication.
+	Header  http.Header
+	Timeout time.Duration
+	// APIError builds the error of a non-2xx response from its status code and body.
+	APIError func(statusCode int, body []byte) error
+}
+
+// Client posts JSON requests to a provider API
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+	// streamClient has no overall timeout, which would cut long generations off mid-stream;
+	// only waiting for the response headers is bounded, and ctx ends the stream.
+	streamClient *http.Client
+}
+
+// New creates a Client; the base URL loses any trailing slash
+func New(cfg Config) *Client {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Client{
+		cfg:          cfg,
+		httpClient:   &http.Client{Timeout: cfg.Timeout},
+		streamClient: newStreamClient(cfg.Timeout),
+	}
+}
+
+// Post sends a JSON request to {BaseURL}{path} and decodes the JSON response into out
+func (c *Client) Post(ctx context.Context, path string, in, out interface{}) error {
+	resp, err := c.send(ctx, c.httpClient, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", c.cfg.Name, err)
+	}
+	return nil
+}
+
+// Stream sends a JSON request to {BaseURL}{path} and returns the response of a 2xx status, whose
+// body the caller reads until ctx ends and then closes
+func (c *Client) Stream(ctx context.Context, path string, in interface{}) (*http.Response, error) {
+	return c.send(ctx, c.streamClient, path, in)
+}
+
+// send sends a JSON request with client, returning the response of a 2xx status and the error
+// built by Config.APIError otherwise
+func (c *Client) send(ctx context.Context, client *http.Client, path string, in interface{}) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.cfg.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.cfg.APIError(resp.StatusCode, data)
+}
+
+// CheckDimension checks that an embedding returned by a provider has the configured dimension
+func CheckDimension(values []float32, dim uint64) error {
+	if uint64(len(values)) != dim {
+		return domain.NewErrInvalidVectorSize(dim, uint64(len(values)))
+	}
+	return nil
+}
+
+// newStreamClient creates a client for streamed responses: headers must arrive within
+// headerTimeout, while the body may take as long as the generation does
+func newStreamClient(headerTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = headerTimeout
+	return &http.Client{Transport: transport}
+}
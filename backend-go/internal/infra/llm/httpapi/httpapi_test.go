@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("%d: %s", e.status, e.body) }
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, timeout time.Duration) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New(Config{
+		Name:     "test",
+		BaseURL:  srv.URL + "/v1/",
+		Header:   http.Header{"Authorization": {"Bearer key"}},
+		Timeout:  timeout,
+		APIError: func(status int, body []byte) error { return &statusError{status, string(body)} },
+	})
+}
+
+func TestPost(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/echo" || r.Header.Get("Authorization") != "Bearer key" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "unexpected request %s", r.URL.Path)
+			return
+		}
+		io.Copy(w, r.Body)
+	}, 0)
+
+	var out map[string]string
+	if err := client.Post(context.Background(), "/echo", map[string]string{"a": "b"}, &out); err != nil || out["a"] != "b" {
+		t.Errorf("Post = %v, %v", out, err)
+	}
+	var apiErr *statusError
+	err := client.Post(context.Background(), "/missing", map[string]string{}, &out)
+	if !errors.As(err, &apiErr) || apiErr.status != http.StatusBadRequest || apiErr.body != "unexpected request /v1/missing" {
+		t.Errorf("expected the API error of the response, got %v", err)
+	}
+}
+
+func TestStreamOutlivesTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, word := range []string{"slow ", "reply"} {
+			json.NewEncoder(w).Encode(word)
+			w.(http.Flusher).Flush()
+			time.Sleep(150 * time.Millisecond)
+		}
+	}, 50*time.Millisecond)
+
+	resp, err := client.Stream(context.Background(), "/stream", nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Errorf("a stream longer than the request timeout was cut off: %v", err)
+	}
+}
+
+func TestCheckDimension(t *testing.T) {
+	if err := CheckDimension([]float32{1, 2, 3}, 3); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := CheckDimension([]float32{1, 2}, 3); !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("expected ErrInvalidVectorSize, got %v", err)
+	}
+}
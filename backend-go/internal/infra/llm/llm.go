@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/ran/demo/backend-go/internal/config"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/llm/fake"
+	"github.com/ran/demo/backend-go/internal/infra/llm/fallback"
+	"github.com/ran/demo/backend-go/internal/infra/llm/gemini"
+	"github.com/ran/demo/backend-go/internal/infra/llm/openai"
+)
+
+// New creates the LLM and embedding model of the provider selected by cfg.Provider
+func New(cfg config.LLMConfig) (ports.LLM, ports.EmbeddingModel, error) {
+	switch cfg.Provider {
+	case config.LLMProviderGemini:
+		client, err := gemini.NewGeminiClient(gemini.Config{
+			APIKey:          cfg.APIKey,
+			BaseURL:         cfg.BaseURL,
+			GenerationModel: cfg.GenerationModel,
+			EmbeddingModel:  cfg.EmbeddingModel,
+			EmbeddingDim:    cfg.EmbeddingDim,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, client, nil
+	case config.LLMProviderOpenAI, config.LLMProviderOllama:
+		client, err := openai.NewOpenAIClient(openai.Config{
+			APIKey:          cfg.APIKey,
+			BaseURL:         cfg.BaseURL,
+			GenerationModel: cfg.GenerationModel,
+			EmbeddingModel:  cfg.EmbeddingModel,
+			EmbeddingDim:    cfg.EmbeddingDim,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, client, nil
+	case config.LLMProviderFake:
+		embedder, err := fake.NewEmbedder(cfg.EmbeddingDim)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fake.NewLLM(), embedder, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown llm provider %q", cfg.Provider)
+	}
+}
+
+// NewChain creates the primary provider followed by the fallback providers, each behind a circuit breaker
+func NewChain(primary config.LLMConfig, fallbacks config.LLMFallbackConfig) (*fallback.Chain, error) {
+	providers := make([]fallback.Provider, 0, 1+len(fallbacks.Providers))
+	for _, cfg := range append([]config.LLMConfig{primary}, fallbacks.Providers...) {
+		llm, embedder, err := New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s provider %q: %w", cfg.Provider, cfg.Name, err)
+		}
+		providers = append(providers, fallback.Provider{Name: cfg.Name, LLM: llm, Embedder: embedder})
+	}
+	return fallback.NewChain(providers, fallback.BreakerConfig{
+		FailureThreshold: fallbacks.FailureThreshold,
+		Cooldown:         fallbacks.Cooldown,
+	})
+}
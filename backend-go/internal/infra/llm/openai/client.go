@@ -0,0 +1,215 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/llm/httpapi"
+	"github.com/ran/demo/backend-go/internal/infra/llm/prompts"
+	"github.com/ran/demo/backend-go/internal/infra/llm/sse"
+)
+
+// maxBatchEmbedSize is the largest number of texts sent in one embeddings call. OpenAI accepts
+// more, but local servers embed a request at once and time out on large ones.
+const maxBatchEmbedSize = 100
+
+// Config holds the settings of an OpenAI-compatible server. BaseURL includes the API version,
+// e.g. https://api.openai.com/v1 or http://localhost:11434/v1 for Ollama. APIKey may be empty
+// for local servers that do not authenticate.
+type Config struct {
+	APIKey          string
+	BaseURL         string
+	GenerationModel string
+	EmbeddingModel  string
+	EmbeddingDim    uint64
+	Timeout         time.Duration
+}
+
+// OpenAIClient implements ports.LLM and ports.EmbeddingModel over the OpenAI chat completions
+// and embeddings API, as served by OpenAI, Ollama, vLLM and llama.cpp
+type OpenAIClient struct {
+	cfg Config
+	api *httpapi.Client
+}
+
+// NewOpenAIClient creates a new client of an OpenAI-compatible server
+func NewOpenAIClient(cfg Config) (*OpenAIClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("openai base url is required")
+	}
+	if cfg.GenerationModel == "" || cfg.EmbeddingModel == "" {
+		return nil, errors.New("openai generation and embedding models are required")
+	}
+	if cfg.EmbeddingDim == 0 {
+		return nil, errors.New("openai embedding dimension must be > 0")
+	}
+	header := http.Header{}
+	if cfg.APIKey != "" {
+		header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+	return &OpenAIClient{
+		cfg: cfg,
+		api: httpapi.New(httpapi.Config{
+			Name:     "openai",
+			BaseURL:  cfg.BaseURL,
+			Header:   header,
+			Timeout:  cfg.Timeout,
+			APIError: newAPIError,
+		}),
+	}, nil
+}
+
+// GenerateCompletion generates a completion for the given prompt
+func (c *OpenAIClient) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	return c.generate(ctx, prompt)
+}
+
+// GenerateSummary generates a concise summary for the given text
+func (c *OpenAIClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	summary, err := c.generate(ctx, prompts.Summary(text))
+	if err != nil {
+		return "", domain.NewErrSummaryGeneration(err)
+	}
+	return summary, nil
+}
+
+// AnswerQuestion answers a question using the given context passages
+func (c *OpenAIClient) AnswerQuestion(ctx context.Context, context []string, question string) (string, error) {
+	return c.generate(ctx, prompts.Answer(context, question))
+}
+
+// StreamCompletion streams a completion for the given prompt
+func (c *OpenAIClient) StreamCompletion(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	return c.stream(ctx, prompt)
+}
+
+// StreamAnswer streams the answer to a question using the given context passages
+func (c *OpenAIClient) StreamAnswer(ctx context.Context, context []string, question string) (<-chan domain.StreamChunk, error) {
+	return c.stream(ctx, prompts.Answer(context, question))
+}
+
+// ExtractKeywords extracts keywords from the given text
+func (c *OpenAIClient) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	out, err := c.generate(ctx, prompts.Keywords(text))
+	if err != nil {
+		return nil, err
+	}
+	return prompts.ParseKeywords(out), nil
+}
+
+// GenerateEmbedding generates a vector embedding for the given text
+func (c *OpenAIClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := c.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// GenerateEmbeddings generates embeddings for a batch of texts, splitting it into requests of at
+// most maxBatchEmbedSize texts
+func (c *OpenAIClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatchEmbedSize {
+		end := min(start+maxBatchEmbedSize, len(texts))
+		var resp embeddingsResponse
+		req := embeddingsRequest{Model: c.cfg.EmbeddingModel, Input: texts[start:end]}
+		if err := c.api.Post(ctx, "/embeddings", req, &resp); err != nil {
+			return nil, domain.NewErrEmbeddingGeneration(err)
+		}
+		if len(resp.Data) != end-start {
+			return nil, domain.NewErrEmbeddingGeneration(fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Data)))
+		}
+		// The data is not guaranteed to be in input order, each entry carries its index
+		batch := make([][]float32, end-start)
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(batch) || batch[d.Index] != nil {
+				return nil, domain.NewErrEmbeddingGeneration(fmt.Errorf("invalid embedding index %d", d.Index))
+			}
+			if err := httpapi.CheckDimension(d.Embedding, c.cfg.EmbeddingDim); err != nil {
+				return nil, domain.NewErrEmbeddingGeneration(err)
+			}
+			batch[d.Index] = d.Embedding
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// GetEmbeddingDimension returns the configured embedding dimension
+func (c *OpenAIClient) GetEmbeddingDimension() uint64 {
+	return c.cfg.EmbeddingDim
+}
+
+func chatRequest(model, prompt string) chatCompletionRequest {
+	return chatCompletionRequest{
+		Model:       model,
+		Messages:    []message{{Role: "user", Content: prompt}},
+		Temperature: 0.2,
+	}
+}
+
+func (c *OpenAIClient) generate(ctx context.Context, prompt string) (string, error) {
+	var resp chatCompletionResponse
+	if err := c.api.Post(ctx, "/chat/completions", chatRequest(c.cfg.GenerationModel, prompt), &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("openai returned no choices")
+	}
+	text := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if text == "" {
+		return "", fmt.Errorf("openai returned empty content (finish reason %q)", resp.Choices[0].FinishReason)
+	}
+	return text, nil
+}
+
+// stream starts a streamed chat completion and relays the content delta of each event until
+// the [DONE] event. Errors before the first event, such as a rejected request, are returned directly.
+func (c *OpenAIClient) stream(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	req := chatRequest(c.cfg.GenerationModel, prompt)
+	req.Stream = true
+	resp, err := c.api.Stream(ctx, "/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan domain.StreamChunk)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		err := sse.Read(resp.Body, func(data string) error {
+			if data == "[DONE]" {
+				return errStreamDone
+			}
+			var chunk chatCompletionChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return fmt.Errorf("failed to decode openai stream event: %w", err)
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+				return nil
+			}
+			select {
+			case out <- domain.StreamChunk{Delta: chunk.Choices[0].Delta.Content}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && !errors.Is(err, errStreamDone) {
+			select {
+			case out <- domain.StreamChunk{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// errStreamDone stops reading a stream at its [DONE] event
+var errStreamDone = errors.New("stream done")
@@ -0,0 +1,237 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var (
+	_ ports.StreamingLLM   = (*OpenAIClient)(nil)
+	_ ports.EmbeddingModel = (*OpenAIClient)(nil)
+)
+
+const testDim = 3
+
+// newStandIn starts a server emulating the OpenAI-compatible endpoints used by OpenAIClient.
+// An empty apiKey accepts unauthenticated requests, as Ollama does.
+func newStandIn(t *testing.T, apiKey, reply string) (*OpenAIClient, *[]chatCompletionRequest, *int) {
+	t.Helper()
+	var chats []chatCompletionRequest
+	embedCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiKey != "" && r.Header.Get("Authorization") != "Bearer "+apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var req chatCompletionRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			chats = append(chats, req)
+			if req.Stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, word := range strings.SplitAfter(reply, " ") {
+					data, _ := json.Marshal(chatCompletionChunk{Choices: []streamChoice{{Delta: message{Content: word}}}})
+					fmt.Fprintf(w, "data: %s\n\n", data)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+				return
+			}
+			json.NewEncoder(w).Encode(chatCompletionResponse{Choices: []choice{{
+				Message:      message{Role: "assistant", Content: reply},
+				FinishReason: "stop",
+			}}})
+		case "/v1/embeddings":
+			embedCalls++
+			var req embeddingsRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			resp := embeddingsResponse{}
+			// Reply in reverse order: clients must place vectors by index
+			for i := len(req.Input) - 1; i >= 0; i-- {
+				n := float32(len(req.Input[i]))
+				resp.Data = append(resp.Data, embeddingData{Index: i, Embedding: []float32{n, 0, 0}})
+			}
+			json.NewEncoder(w).Encode(resp)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewOpenAIClient(Config{
+		APIKey:          apiKey,
+		BaseURL:         srv.URL + "/v1/",
+		GenerationModel: "chat-test",
+		EmbeddingModel:  "embed-test",
+		EmbeddingDim:    testDim,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client, &chats, &embedCalls
+}
+
+// withConfig creates a client of the same server with its configuration changed by fn
+func withConfig(t *testing.T, client *OpenAIClient, fn func(*Config)) *OpenAIClient {
+	t.Helper()
+	cfg := client.cfg
+	fn(&cfg)
+	changed, err := NewOpenAIClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return changed
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	client, chats, _ := newStandIn(t, "test-key", " Go, testing, 検索 , go ")
+	ctx := context.Background()
+
+	summary, err := client.GenerateSummary(ctx, "text")
+	if err != nil || summary != "Go, testing, 検索 , go" {
+		t.Errorf("GenerateSummary = %q, %v", summary, err)
+	}
+	keywords, err := client.ExtractKeywords(ctx, "text")
+	if err != nil {
+		t.Fatalf("ExtractKeywords failed: %v", err)
+	}
+	if strings.Join(keywords, "|") != "Go|testing|検索" {
+		t.Errorf("keywords = %v", keywords)
+	}
+	if _, err := client.AnswerQuestion(ctx, []string{"Qdrant is a vector database."}, "What is Qdrant?"); err != nil {
+		t.Fatalf("AnswerQuestion failed: %v", err)
+	}
+	last := (*chats)[len(*chats)-1]
+	if last.Model != "chat-test" || len(last.Messages) != 1 || !strings.Contains(last.Messages[0].Content, "[1] Qdrant is a vector database.") {
+		t.Errorf("unexpected chat request: %+v", last)
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	client, chats, _ := newStandIn(t, "test-key", "Qdrant is a vector database.")
+	stream, err := client.StreamCompletion(context.Background(), "What is Qdrant?")
+	if err != nil {
+		t.Fatalf("StreamCompletion failed: %v", err)
+	}
+	var deltas []string
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream failed: %v", chunk.Err)
+		}
+		deltas = append(deltas, chunk.Delta)
+	}
+	if len(deltas) != 5 || strings.Join(deltas, "") != "Qdrant is a vector database." {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if !(*chats)[0].Stream {
+		t.Error("the request should ask for a stream")
+	}
+
+	// Stopping early must not block the producer
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err = client.StreamAnswer(ctx, []string{"passage"}, "question")
+	if err != nil {
+		t.Fatalf("StreamAnswer failed: %v", err)
+	}
+	<-stream
+	cancel()
+	for range stream {
+	}
+}
+
+func TestOpenAIEmbeddingsBatchingWithoutKey(t *testing.T) {
+	client, _, embedCalls := newStandIn(t, "", "")
+	texts := make([]string, maxBatchEmbedSize+5)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+
+	vectors, err := client.GenerateEmbeddings(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings failed: %v", err)
+	}
+	if *embedCalls != 2 {
+		t.Errorf("embedding calls = %d, want 2", *embedCalls)
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i+1) {
+			t.Fatalf("vector %d out of order: %v", i, v)
+		}
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	client, _, _ := newStandIn(t, "test-key", "")
+	ctx := context.Background()
+
+	client = withConfig(t, client, func(cfg *Config) { cfg.APIKey = "wrong" })
+	_, err := client.GenerateSummary(ctx, "text")
+	var apiErr *APIError
+	if !errors.Is(err, domain.ErrSummaryGeneration) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Type != "invalid_request_error" {
+		t.Errorf("unexpected summary error: %v", err)
+	}
+	_, err = client.GenerateEmbedding(ctx, "text")
+	var statusErr domain.HTTPStatusError
+	if !errors.Is(err, domain.ErrEmbeddingGeneration) || !errors.As(err, &statusErr) || statusErr.HTTPStatus() != http.StatusUnauthorized {
+		t.Errorf("unexpected embedding error: %v", err)
+	}
+
+	client = withConfig(t, client, func(cfg *Config) {
+		cfg.APIKey = "test-key"
+		cfg.EmbeddingDim = 4
+	})
+	_, err = client.GenerateEmbedding(ctx, "text")
+	if !errors.Is(err, domain.ErrEmbeddingGeneration) || !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("expected dimension error, got %v", err)
+	}
+}
+
+func TestOpenAIStreamOutlivesTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range []string{"slow ", "reply"} {
+			data, _ := json.Marshal(chatCompletionChunk{Choices: []streamChoice{{Delta: message{Content: word}}}})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+			time.Sleep(150 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	client, err := NewOpenAIClient(Config{
+		BaseURL:         srv.URL,
+		GenerationModel: "chat-test",
+		EmbeddingModel:  "embed-test",
+		EmbeddingDim:    testDim,
+		Timeout:         50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	stream, err := client.StreamCompletion(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("StreamCompletion failed: %v", err)
+	}
+	var reply strings.Builder
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("a stream longer than the request timeout was cut off: %v", chunk.Err)
+		}
+		reply.WriteString(chunk.Delta)
+	}
+	if reply.String() != "slow reply" {
+		t.Errorf("reply = %q", reply.String())
+	}
+}
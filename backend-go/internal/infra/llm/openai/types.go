@@ -0,0 +1,90 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Wire types for the OpenAI-compatible REST API (/v1)
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+type choice struct {
+	Index        int     `json:"index"`
+	Message      message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+type chatCompletionResponse struct {
+	Choices []choice `json:"choices"`
+}
+
+type streamChoice struct {
+	Index        int     `json:"index"`
+	Delta        message `json:"delta"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// chatCompletionChunk is one event of a streamed chat completion
+type chatCompletionChunk struct {
+	Choices []streamChoice `json:"choices"`
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingData struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+type embeddingsResponse struct {
+	Data []embeddingData `json:"data"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// APIError is returned when the server responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("openai api error %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// HTTPStatus implements domain.HTTPStatusError
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// newAPIError builds the error of a non-2xx response, preferring the message of a JSON error body
+func newAPIError(statusCode int, body []byte) error {
+	apiErr := &APIError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+	var errResp errorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,56 @@
+// Package prompts holds the prompts shared by the LLM provider adapters
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxKeywords caps the number of keywords requested per text
+const MaxKeywords = 10
+
+// Summary asks for a summary of text
+func Summary(text string) string {
+	return "Summarize the following document in a few sentences. " +
+		"Write the summary in the same language as the document.\n\n" + text
+}
+
+// Answer asks for an answer to question grounded in the numbered passages
+func Answer(passages []string, question string) string {
+	var sb strings.Builder
+	sb.WriteString("Answer the question using only the context below. ")
+	sb.WriteString("If the context does not contain the answer, say that you do not know.\n\nContext:\n")
+	for i, p := range passages {
+		fmt.Fprintf(&sb, "[%d] %s\n", i+1, p)
+	}
+	sb.WriteString("\nQuestion: ")
+	sb.WriteString(question)
+	return sb.String()
+}
+
+// Keywords asks for the keywords of text
+func Keywords(text string) string {
+	return fmt.Sprintf("Extract at most %d keywords from the following text. "+
+		"Reply with the keywords only, separated by commas, in the same language as the text.\n\n%s", MaxKeywords, text)
+}
+
+// ParseKeywords splits a comma or newline separated model reply into unique keywords
+func ParseKeywords(reply string) []string {
+	fields := strings.FieldsFunc(reply, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '、'
+	})
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, f := range fields {
+		kw := strings.Trim(strings.TrimSpace(f), "-*•\"'` ")
+		if kw == "" || seen[strings.ToLower(kw)] {
+			continue
+		}
+		seen[strings.ToLower(kw)] = true
+		keywords = append(keywords, kw)
+		if len(keywords) == MaxKeywords {
+			break
+		}
+	}
+	return keywords
+}
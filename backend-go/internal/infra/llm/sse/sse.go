@@ -0,0 +1,45 @@
+// Package sse reads Server-Sent Event streams, as returned by the streaming endpoints of LLM providers
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// maxLineSize bounds a single line of the stream
+const maxLineSize = 1 << 20
+
+// Read calls fn with the data of each event in r, until r ends or fn returns an error. The data
+// lines of an event are joined with newlines; comments, other fields and events without data
+// are skipped. fn's error is returned as is.
+func Read(r io.Reader, fn func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	var data []string
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		event := strings.Join(data, "\n")
+		data = data[:0]
+		return fn(event)
+	}
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		if field == "data" {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,37 @@
+package sse
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	stream := ": keep-alive\r\n\r\n" +
+		"event: message\ndata: {\"a\":1}\n\n" +
+		"data: first line\ndata:second line\nid: 7\n\n" +
+		"data: [DONE]"
+	var got []string
+	err := Read(strings.NewReader(stream), func(data string) error {
+		got = append(got, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	want := []string{`{"a":1}`, "first line\nsecond line", "[DONE]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = Read(strings.NewReader(stream), func(string) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Read should stop at the first error, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,194 @@
+// Package mlnative implements ports.VectorAnalysisService in pure Go (PCA + k-means with soft memberships).
+// It needs no external service, so it serves as a fallback for the Python ML service
+// and as the default for small vector spaces where a round trip is not worth it.
+package mlnative
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// K selection strategies
+const (
+	KSelectionSilhouette = "silhouette"
+	KSelectionElbow      = "elbow"
+)
+
+// maxSilhouetteSize is the largest input scored by silhouette; its O(n²) distance
+// matrix gets too expensive beyond that, so larger inputs use the elbow method.
+const maxSilhouetteSize = 2000
+
+// Config holds the settings of a NativeAnalyzer
+type Config struct {
+	// Dimensions is the number of output components of Reduce (2 or 3).
+	Dimensions int
+	// MaxClusters caps the k values tried by Cluster. Defaults to 10.
+	MaxClusters int
+	// KSelection is KSelectionSilhouette (default) or KSelectionElbow.
+	KSelection string
+	// Seed makes k-means++ seeding reproducible.
+	Seed int64
+	// MaxIterations bounds Lloyd iterations per k-means run. Defaults to 100.
+	MaxIterations int
+}
+
+// NativeAnalyzer implements ports.VectorAnalysisService without calling out to the ML service
+type NativeAnalyzer struct {
+	cfg Config
+}
+
+// NewNativeAnalyzer creates a new native analyzer
+func NewNativeAnalyzer(cfg Config) (*NativeAnalyzer, error) {
+	if cfg.Dimensions != 2 && cfg.Dimensions != 3 {
+		return nil, fmt.Errorf("native analyzer dimensions must be 2 or 3, got %d", cfg.Dimensions)
+	}
+	switch cfg.KSelection {
+	case "":
+		cfg.KSelection = KSelectionSilhouette
+	case KSelectionSilhouette, KSelectionElbow:
+	default:
+		return nil, fmt.Errorf("unknown k selection %q", cfg.KSelection)
+	}
+	if cfg.MaxClusters <= 0 {
+		cfg.MaxClusters = 10
+	}
+	if cfg.MaxIterations <= 0 {
+		cfg.MaxIterations = 100
+	}
+	return &NativeAnalyzer{cfg: cfg}, nil
+}
+
+// Reduce projects vectors onto their top cfg.Dimensions principal components.
+// Components missing because the input has too few points or too little variance are zero.
+func (a *NativeAnalyzer) Reduce(ctx context.Context, vectors [][]float32) ([][]float32, error) {
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	x, err := toFloat64(vectors)
+	if err != nil {
+		return nil, domain.NewErrVectorAnalysis(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	projected := pca(x, a.cfg.Dimensions)
+	coords := make([][]float32, len(projected))
+	for i, row := range projected {
+		coords[i] = make([]float32, len(row))
+		for j, v := range row {
+			coords[i][j] = float32(v)
+		}
+	}
+	return coords, nil
+}
+
+// Cluster groups vectors with k-means on their L2-normalized form, choosing k by
+// silhouette or elbow. Membership probabilities are fuzzy c-means memberships around the
+// k-means centroids. Labels are numbered by first member; MemberIDs are input positions.
+func (a *NativeAnalyzer) Cluster(ctx context.Context, vectors [][]float32, opts domain.ClusterOptions) ([]domain.Cluster, error) {
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	x, err := toFloat64(vectors)
+	if err != nil {
+		return nil, domain.NewErrVectorAnalysis(err)
+	}
+	for _, row := range x {
+		normalize(row)
+	}
+	n := len(x)
+	maxK := a.cfg.MaxClusters
+	if maxK > n-1 {
+		maxK = n - 1
+	}
+	if maxK < 2 {
+		return toClusters(make([]int, n), nil, opts.MinProbability), nil
+	}
+
+	selection := a.cfg.KSelection
+	if n > maxSilhouetteSize {
+		selection = KSelectionElbow
+	}
+	var dist [][]float64
+	if selection == KSelectionSilhouette {
+		dist = pairwiseDistances(x)
+	}
+
+	var (
+		ks       []int
+		runs     []kmeansResult
+		inertias []float64
+	)
+	bestIdx, bestScore := 0, math.Inf(-1)
+	for k := 2; k <= maxK; k++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		run := kmeans(x, k, a.cfg.MaxIterations, rand.New(rand.NewSource(a.cfg.Seed+int64(k))))
+		ks = append(ks, k)
+		runs = append(runs, run)
+		inertias = append(inertias, run.inertia)
+		if selection == KSelectionSilhouette {
+			if score := silhouette(dist, run.labels, k); score > bestScore {
+				bestIdx, bestScore = len(runs)-1, score
+			}
+		}
+	}
+	if selection == KSelectionElbow {
+		chosen := elbow(ks, inertias)
+		bestIdx = chosen - ks[0]
+	}
+	best := runs[bestIdx]
+	return toClusters(best.labels, memberships(x, best), opts.MinProbability), nil
+}
+
+// toClusters converts per-point labels into clusters, dropping empty ones and renumbering
+// labels in order of their first member so output is stable. When probs is set, each point
+// also joins every other cluster whose probability reaches minProbability (if positive).
+func toClusters(labels []int, probs [][]float64, minProbability float64) []domain.Cluster {
+	renumber := make(map[int]int)
+	var order []int
+	for _, l := range labels {
+		if _, ok := renumber[l]; !ok {
+			renumber[l] = len(order)
+			order = append(order, l)
+		}
+	}
+	clusters := make([]domain.Cluster, len(order))
+	for idx := range clusters {
+		clusters[idx].Label = idx
+	}
+	for i, l := range labels {
+		for _, raw := range order {
+			if raw != l && (probs == nil || minProbability <= 0 || probs[i][raw] < minProbability) {
+				continue
+			}
+			cl := &clusters[renumber[raw]]
+			cl.MemberIDs = append(cl.MemberIDs, strconv.Itoa(i))
+			if probs != nil {
+				cl.Probabilities = append(cl.Probabilities, probs[i][raw])
+			}
+		}
+	}
+	return clusters
+}
+
+func toFloat64(vectors [][]float32) ([][]float64, error) {
+	dim := len(vectors[0])
+	x := make([][]float64, len(vectors))
+	for i, v := range vectors {
+		if len(v) != dim {
+			return nil, fmt.Errorf("vector %d has %d dimensions, want %d", i, len(v), dim)
+		}
+		x[i] = make([]float64, dim)
+		for j, f := range v {
+			x[i][j] = float64(f)
+		}
+	}
+	return x, nil
+}
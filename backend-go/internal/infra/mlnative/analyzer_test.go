@@ -0,0 +1,141 @@
+package mlnative
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var _ ports.VectorAnalysisService = (*NativeAnalyzer)(nil)
+
+// blobs returns n points per center, jittered deterministically around each center
+func blobs(centers [][]float32, n int) [][]float32 {
+	var out [][]float32
+	for _, c := range centers {
+		for i := 0; i < n; i++ {
+			v := make([]float32, len(c))
+			for j := range c {
+				v[j] = c[j] + 0.05*float32(math.Sin(float64(i*len(c)+j+1)))
+			}
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func newTestAnalyzer(t *testing.T, cfg Config) *NativeAnalyzer {
+	t.Helper()
+	a, err := NewNativeAnalyzer(cfg)
+	if err != nil {
+		t.Fatalf("failed to create analyzer: %v", err)
+	}
+	return a
+}
+
+func TestReduceFindsDominantAxis(t *testing.T) {
+	a := newTestAnalyzer(t, Config{Dimensions: 2})
+	// Points spread along x with small noise in y and z
+	var vectors [][]float32
+	for i := -5; i <= 5; i++ {
+		vectors = append(vectors, []float32{float32(i), 0.01 * float32(i%2), 0.02 * float32(i%3)})
+	}
+
+	coords, err := a.Reduce(context.Background(), vectors)
+	if err != nil {
+		t.Fatalf("Reduce failed: %v", err)
+	}
+	if len(coords) != len(vectors) || len(coords[0]) != 2 {
+		t.Fatalf("unexpected shape: %d x %d", len(coords), len(coords[0]))
+	}
+	for i, c := range coords {
+		if math.Abs(math.Abs(float64(c[0]))-math.Abs(float64(vectors[i][0]))) > 0.05 {
+			t.Errorf("point %d: first component %v does not follow x=%v", i, c[0], vectors[i][0])
+		}
+	}
+
+	again, _ := a.Reduce(context.Background(), vectors)
+	if !reflect.DeepEqual(coords, again) {
+		t.Error("Reduce is not deterministic")
+	}
+
+	a3 := newTestAnalyzer(t, Config{Dimensions: 3})
+	coords3, err := a3.Reduce(context.Background(), vectors[:2])
+	if err != nil || len(coords3[0]) != 3 {
+		t.Errorf("expected 3 components for tiny input, got %v (%v)", coords3, err)
+	}
+}
+
+func TestClusterSeparatesBlobs(t *testing.T) {
+	centers := [][]float32{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}}
+	vectors := blobs(centers, 6)
+
+	for _, selection := range []string{KSelectionSilhouette, KSelectionElbow} {
+		a := newTestAnalyzer(t, Config{Dimensions: 2, MaxClusters: 6, KSelection: selection, Seed: 1})
+		clusters, err := a.Cluster(context.Background(), vectors, domain.ClusterOptions{})
+		if err != nil {
+			t.Fatalf("%s: Cluster failed: %v", selection, err)
+		}
+		if len(clusters) != 3 {
+			t.Fatalf("%s: got %d clusters, want 3: %+v", selection, len(clusters), clusters)
+		}
+		want := []string{"0,1,2,3,4,5", "6,7,8,9,10,11", "12,13,14,15,16,17"}
+		for i, cl := range clusters {
+			if cl.Label != i || strings.Join(cl.MemberIDs, ",") != want[i] {
+				t.Errorf("%s: cluster %d = %+v, want members %s", selection, i, cl, want[i])
+			}
+		}
+	}
+}
+
+func TestClusterSoftMembership(t *testing.T) {
+	vectors := blobs([][]float32{{1, 0, 0}, {0, 1, 0}}, 6)
+	// Halfway between the two blobs
+	vectors = append(vectors, []float32{1, 1, 0})
+	a := newTestAnalyzer(t, Config{Dimensions: 2, MaxClusters: 2, Seed: 1})
+
+	hard, err := a.Cluster(context.Background(), vectors, domain.ClusterOptions{})
+	if err != nil {
+		t.Fatalf("Cluster failed: %v", err)
+	}
+	soft, err := a.Cluster(context.Background(), vectors, domain.ClusterOptions{MinProbability: 0.2})
+	if err != nil {
+		t.Fatalf("Cluster failed: %v", err)
+	}
+	if len(hard) != 2 || len(soft) != 2 {
+		t.Fatalf("expected 2 clusters, got hard=%+v soft=%+v", hard, soft)
+	}
+	if len(hard[0].MemberIDs)+len(hard[1].MemberIDs) != len(vectors) {
+		t.Errorf("hard clustering must list each vector once: %+v", hard)
+	}
+	for _, cl := range soft {
+		if len(cl.Probabilities) != len(cl.MemberIDs) {
+			t.Fatalf("probabilities not parallel to members: %+v", cl)
+		}
+		last := len(cl.MemberIDs) - 1
+		if cl.MemberIDs[last] != "12" || math.Abs(cl.Probabilities[last]-0.5) > 0.1 {
+			t.Errorf("midpoint should belong to cluster %d with p≈0.5: %+v", cl.Label, cl)
+		}
+		if cl.Probabilities[0] < 0.99 {
+			t.Errorf("blob member should be certain, got %v", cl.Probabilities[0])
+		}
+	}
+}
+
+func TestClusterTinyInput(t *testing.T) {
+	a := newTestAnalyzer(t, Config{Dimensions: 2})
+	clusters, err := a.Cluster(context.Background(), [][]float32{{1, 0}, {0, 1}}, domain.ClusterOptions{})
+	if err != nil {
+		t.Fatalf("Cluster failed: %v", err)
+	}
+	if len(clusters) != 1 || len(clusters[0].MemberIDs) != 2 {
+		t.Errorf("expected a single cluster, got %+v", clusters)
+	}
+	if _, err := a.Cluster(context.Background(), [][]float32{{1, 0}, {0}}, domain.ClusterOptions{}); err == nil {
+		t.Error("expected error for ragged input")
+	}
+}
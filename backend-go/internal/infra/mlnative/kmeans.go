@@ -0,0 +1,225 @@
+package mlnative
+
+import (
+	"math"
+	"math/rand"
+)
+
+// kmeansResult holds one k-means run
+type kmeansResult struct {
+	labels    []int
+	centroids [][]float64
+	inertia   float64
+}
+
+// kmeans runs Lloyd's algorithm with k-means++ seeding. The rng makes runs reproducible.
+func kmeans(x [][]float64, k, maxIterations int, rng *rand.Rand) kmeansResult {
+	n := len(x)
+	centroids := seedCentroids(x, k, rng)
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = -1
+	}
+	for it := 0; it < maxIterations; it++ {
+		changed := false
+		for i, row := range x {
+			best := nearest(row, centroids)
+			if best != labels[i] {
+				labels[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		centroids = recompute(x, labels, centroids)
+	}
+	var inertia float64
+	for i, row := range x {
+		inertia += sqDist(row, centroids[labels[i]])
+	}
+	return kmeansResult{labels: labels, centroids: centroids, inertia: inertia}
+}
+
+func seedCentroids(x [][]float64, k int, rng *rand.Rand) [][]float64 {
+	centroids := [][]float64{append([]float64(nil), x[rng.Intn(len(x))]...)}
+	dists := make([]float64, len(x))
+	for len(centroids) < k {
+		var total float64
+		for i, row := range x {
+			dists[i] = sqDist(row, centroids[nearest(row, centroids)])
+			total += dists[i]
+		}
+		if total == 0 {
+			// Fewer distinct points than k; duplicate a centroid, it will end up empty.
+			centroids = append(centroids, append([]float64(nil), centroids[0]...))
+			continue
+		}
+		target := rng.Float64() * total
+		chosen := len(x) - 1
+		for i, d := range dists {
+			target -= d
+			if target <= 0 {
+				chosen = i
+				break
+			}
+		}
+		centroids = append(centroids, append([]float64(nil), x[chosen]...))
+	}
+	return centroids
+}
+
+func recompute(x [][]float64, labels []int, prev [][]float64) [][]float64 {
+	d := len(x[0])
+	sums := make([][]float64, len(prev))
+	counts := make([]int, len(prev))
+	for c := range sums {
+		sums[c] = make([]float64, d)
+	}
+	for i, row := range x {
+		c := labels[i]
+		counts[c]++
+		for j, v := range row {
+			sums[c][j] += v
+		}
+	}
+	for c := range sums {
+		if counts[c] == 0 {
+			// Keep an empty cluster's centroid where it was
+			sums[c] = prev[c]
+			continue
+		}
+		for j := range sums[c] {
+			sums[c][j] /= float64(counts[c])
+		}
+	}
+	return sums
+}
+
+func nearest(row []float64, centroids [][]float64) int {
+	best, bestDist := 0, math.Inf(1)
+	for c, centroid := range centroids {
+		if d := sqDist(row, centroid); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+func sqDist(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		diff := a[i] - b[i]
+		s += diff * diff
+	}
+	return s
+}
+
+// memberships returns fuzzy c-means memberships (fuzzifier 2) for the centroids of a k-means run:
+// u[i][c] = 1 / Σ_j (d(i,c) / d(i,j))². Each row sums to 1, and unlike a Gaussian posterior it
+// degrades smoothly with relative distance, so points between clusters get split memberships.
+func memberships(x [][]float64, run kmeansResult) [][]float64 {
+	k := len(run.centroids)
+	sizes := make([]int, k)
+	for _, l := range run.labels {
+		sizes[l]++
+	}
+	out := make([][]float64, len(x))
+	for i, row := range x {
+		out[i] = make([]float64, k)
+		dists := make([]float64, k)
+		exact := -1
+		for c, centroid := range run.centroids {
+			if sizes[c] == 0 {
+				continue
+			}
+			dists[c] = sqDist(row, centroid)
+			if dists[c] == 0 {
+				exact = c
+			}
+		}
+		if exact >= 0 {
+			out[i][exact] = 1
+			continue
+		}
+		var total float64
+		for c := range dists {
+			if sizes[c] > 0 {
+				// With fuzzifier 2 the exponent on squared distances is 1
+				out[i][c] = 1 / dists[c]
+				total += out[i][c]
+			}
+		}
+		for c := range out[i] {
+			out[i][c] /= total
+		}
+	}
+	return out
+}
+
+// silhouette returns the mean silhouette coefficient of labels given a pairwise distance matrix
+func silhouette(dist [][]float64, labels []int, k int) float64 {
+	n := len(labels)
+	sizes := make([]int, k)
+	for _, l := range labels {
+		sizes[l]++
+	}
+	var total float64
+	for i := 0; i < n; i++ {
+		if sizes[labels[i]] <= 1 {
+			continue // silhouette of a singleton is 0
+		}
+		sums := make([]float64, k)
+		for j := 0; j < n; j++ {
+			if i != j {
+				sums[labels[j]] += dist[i][j]
+			}
+		}
+		a := sums[labels[i]] / float64(sizes[labels[i]]-1)
+		b := math.Inf(1)
+		for c := 0; c < k; c++ {
+			if c != labels[i] && sizes[c] > 0 {
+				b = math.Min(b, sums[c]/float64(sizes[c]))
+			}
+		}
+		if math.IsInf(b, 1) {
+			continue
+		}
+		total += (b - a) / math.Max(a, b)
+	}
+	return total / float64(n)
+}
+
+func pairwiseDistances(x [][]float64) [][]float64 {
+	dist := make([][]float64, len(x))
+	for i := range dist {
+		dist[i] = make([]float64, len(x))
+	}
+	for i := range x {
+		for j := 0; j < i; j++ {
+			d := math.Sqrt(sqDist(x[i], x[j]))
+			dist[i][j] = d
+			dist[j][i] = d
+		}
+	}
+	return dist
+}
+
+// elbow picks the k whose inertia lies furthest below the straight line joining the first and last candidates
+func elbow(ks []int, inertias []float64) int {
+	if len(ks) < 3 {
+		return ks[0]
+	}
+	last := len(ks) - 1
+	x1, y1 := float64(ks[0]), inertias[0]
+	x2, y2 := float64(ks[last]), inertias[last]
+	best, bestDist := ks[0], -1.0
+	for i := range ks {
+		// Distance of (k, inertia) from the line through the end points
+		d := math.Abs((y2-y1)*float64(ks[i])-(x2-x1)*inertias[i]+x2*y1-y2*x1) / math.Hypot(y2-y1, x2-x1)
+		if d > bestDist {
+			best, bestDist = ks[i], d
+		}
+	}
+	return best
+}
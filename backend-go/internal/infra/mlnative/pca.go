@@ -0,0 +1,185 @@
+package mlnative
+
+import "math"
+
+// powerIterations bounds the power method per component
+const powerIterations = 500
+
+// convergenceTol stops the power method once the eigenvector moves less than this
+const convergenceTol = 1e-10
+
+// pca projects rows of x onto their top k principal components.
+// It eigen-decomposes whichever of the Gram (n×n) or covariance (d×d) matrix is smaller,
+// so it stays cheap for both "few long embeddings" and "many short vectors".
+func pca(x [][]float64, k int) [][]float64 {
+	n := len(x)
+	out := make([][]float64, n)
+	for i := range out {
+		out[i] = make([]float64, k)
+	}
+	if n < 2 {
+		return out
+	}
+	d := len(x[0])
+	centered := center(x)
+
+	if n <= d {
+		// Eigenvectors u of X·Xᵀ give projections directly: X·v = u·sqrt(λ).
+		gram := make([][]float64, n)
+		for i := range gram {
+			gram[i] = make([]float64, n)
+			for j := 0; j <= i; j++ {
+				v := dot(centered[i], centered[j])
+				gram[i][j] = v
+				gram[j][i] = v
+			}
+		}
+		for c, eig := range topEigen(gram, k) {
+			scale := math.Sqrt(math.Max(eig.value, 0))
+			for i := range out {
+				out[i][c] = eig.vector[i] * scale
+			}
+		}
+		return out
+	}
+
+	cov := make([][]float64, d)
+	for i := range cov {
+		cov[i] = make([]float64, d)
+	}
+	for _, row := range centered {
+		for i := 0; i < d; i++ {
+			for j := 0; j <= i; j++ {
+				cov[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	for i := 0; i < d; i++ {
+		for j := 0; j < i; j++ {
+			cov[j][i] = cov[i][j]
+		}
+	}
+	for c, eig := range topEigen(cov, k) {
+		for i, row := range centered {
+			out[i][c] = dot(row, eig.vector)
+		}
+	}
+	return out
+}
+
+type eigenpair struct {
+	value  float64
+	vector []float64
+}
+
+// topEigen returns up to k leading eigenpairs of the symmetric matrix m using power iteration
+// with deflation. Signs are fixed so the largest-magnitude entry is positive, making output deterministic.
+func topEigen(m [][]float64, k int) []eigenpair {
+	size := len(m)
+	a := make([][]float64, size)
+	for i := range m {
+		a[i] = append([]float64(nil), m[i]...)
+	}
+	var pairs []eigenpair
+	for c := 0; c < k && c < size; c++ {
+		v := make([]float64, size)
+		for i := range v {
+			// Deterministic, non-degenerate start vector
+			v[i] = 1 + float64(i%7)*0.1
+		}
+		normalize(v)
+		var value float64
+		for it := 0; it < powerIterations; it++ {
+			next := matVec(a, v)
+			norm := math.Sqrt(dot(next, next))
+			if norm == 0 {
+				value = 0
+				break
+			}
+			for i := range next {
+				next[i] /= norm
+			}
+			delta := 0.0
+			for i := range next {
+				delta += math.Abs(next[i] - v[i])
+			}
+			v = next
+			value = norm
+			if delta < convergenceTol {
+				break
+			}
+		}
+		if value <= 1e-12 {
+			break
+		}
+		fixSign(v)
+		pairs = append(pairs, eigenpair{value: value, vector: v})
+		for i := range a {
+			for j := range a[i] {
+				a[i][j] -= value * v[i] * v[j]
+			}
+		}
+	}
+	return pairs
+}
+
+func center(x [][]float64) [][]float64 {
+	d := len(x[0])
+	mean := make([]float64, d)
+	for _, row := range x {
+		for j, v := range row {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(len(x))
+	}
+	out := make([][]float64, len(x))
+	for i, row := range x {
+		out[i] = make([]float64, d)
+		for j, v := range row {
+			out[i][j] = v - mean[j]
+		}
+	}
+	return out
+}
+
+func matVec(m [][]float64, v []float64) []float64 {
+	out := make([]float64, len(m))
+	for i, row := range m {
+		out[i] = dot(row, v)
+	}
+	return out
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func normalize(v []float64) {
+	norm := math.Sqrt(dot(v, v))
+	if norm == 0 {
+		return
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+func fixSign(v []float64) {
+	maxIdx := 0
+	for i := range v {
+		if math.Abs(v[i]) > math.Abs(v[maxIdx]) {
+			maxIdx = i
+		}
+	}
+	if v[maxIdx] < 0 {
+		for i := range v {
+			v[i] = -v[i]
+		}
+	}
+}
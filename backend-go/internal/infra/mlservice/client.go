@@ -0,0 +1,267 @@
+package mlservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// requestOverhead is the allowance for JSON fields other than the vectors themselves
+const requestOverhead = 256
+
+// Config holds the settings of an MLServiceClient
+type Config struct {
+	BaseURL string
+	// Timeout bounds each HTTP attempt.
+	Timeout time.Duration
+	// MaxRetries is the number of extra attempts after a network error, 429 or 5xx.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles on each further attempt.
+	RetryBackoff time.Duration
+	// MaxPayloadBytes is the largest request body sent in one call; bigger inputs are uploaded in batches.
+	MaxPayloadBytes int
+	// Dimensions is the number of output components of Reduce (2 or 3).
+	Dimensions int
+}
+
+// APIError is returned when the ML service responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Detail     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ml service error %d: %s", e.StatusCode, e.Detail)
+}
+
+// MLServiceClient implements ports.VectorAnalysisService against the Python ML service
+type MLServiceClient struct {
+	cfg        Config
+	httpClient *http.Client
+}
+
+// NewMLServiceClient creates a new ML service client
+func NewMLServiceClient(cfg Config) (*MLServiceClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("ml service base url is required")
+	}
+	if cfg.Dimensions != 2 && cfg.Dimensions != 3 {
+		return nil, fmt.Errorf("ml service dimensions must be 2 or 3, got %d", cfg.Dimensions)
+	}
+	if cfg.MaxPayloadBytes <= requestOverhead {
+		return nil, fmt.Errorf("ml service max payload must be > %d bytes", requestOverhead)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &MLServiceClient{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Reduce projects vectors to cfg.Dimensions components (UMAP on the Python side)
+func (c *MLServiceClient) Reduce(ctx context.Context, vectors [][]float32) ([][]float32, error) {
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	req := reduceRequest{NComponents: c.cfg.Dimensions}
+	cleanup, err := c.attachVectors(ctx, vectors, &req.Vectors, &req.DatasetID)
+	if err != nil {
+		return nil, domain.NewErrVectorAnalysis(err)
+	}
+	defer cleanup()
+
+	var resp reduceResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/reduce", req, &resp); err != nil {
+		return nil, domain.NewErrVectorAnalysis(err)
+	}
+	if len(resp.Coordinates) != len(vectors) {
+		return nil, domain.NewErrVectorAnalysis(fmt.Errorf("expected %d coordinates, got %d", len(vectors), len(resp.Coordinates)))
+	}
+	for i, row := range resp.Coordinates {
+		if len(row) != c.cfg.Dimensions {
+			return nil, domain.NewErrVectorAnalysis(fmt.Errorf("coordinate %d has %d components, want %d", i, len(row), c.cfg.Dimensions))
+		}
+	}
+	return resp.Coordinates, nil
+}
+
+// Cluster groups vectors (HDBSCAN on the Python side). MemberIDs are input positions.
+func (c *MLServiceClient) Cluster(ctx context.Context, vectors [][]float32, opts domain.ClusterOptions) ([]domain.Cluster, error) {
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	req := clusterRequest{MinProbability: opts.MinProbability}
+	cleanup, err := c.attachVectors(ctx, vectors, &req.Vectors, &req.DatasetID)
+	if err != nil {
+		return nil, domain.NewErrVectorAnalysis(err)
+	}
+	defer cleanup()
+
+	var resp clusterResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/cluster", req, &resp); err != nil {
+		return nil, domain.NewErrVectorAnalysis(err)
+	}
+	clusters := make([]domain.Cluster, 0, len(resp.Clusters))
+	for _, cl := range resp.Clusters {
+		for _, member := range cl.MemberIDs {
+			if pos, err := strconv.Atoi(member); err != nil || pos < 0 || pos >= len(vectors) {
+				return nil, domain.NewErrVectorAnalysis(fmt.Errorf("invalid member id %q in cluster %d", member, cl.Label))
+			}
+		}
+		if cl.Probabilities != nil && len(cl.Probabilities) != len(cl.MemberIDs) {
+			return nil, domain.NewErrVectorAnalysis(fmt.Errorf("cluster %d has %d probabilities for %d members", cl.Label, len(cl.Probabilities), len(cl.MemberIDs)))
+		}
+		clusters = append(clusters, domain.Cluster{Label: cl.Label, MemberIDs: cl.MemberIDs, Probabilities: cl.Probabilities})
+	}
+	return clusters, nil
+}
+
+// attachVectors sets inline to vectors when they fit in one request; otherwise it uploads them
+// to a temporary dataset and sets datasetID. The returned cleanup deletes that dataset.
+func (c *MLServiceClient) attachVectors(ctx context.Context, vectors [][]float32, inline *[][]float32, datasetID *string) (func(), error) {
+	batches, err := c.batchBySize(vectors)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) == 1 {
+		*inline = vectors
+		return func() {}, nil
+	}
+
+	var created createDatasetResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/datasets", struct{}{}, &created); err != nil {
+		return nil, fmt.Errorf("failed to create dataset: %w", err)
+	}
+	cleanup := func() {
+		// Use a fresh context so the dataset is removed even if ctx was cancelled.
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+		defer cancel()
+		c.do(cleanupCtx, http.MethodDelete, "/v1/datasets/"+created.DatasetID, nil, nil)
+	}
+	offset := 0
+	for _, batch := range batches {
+		var appended appendVectorsResponse
+		req := appendVectorsRequest{Offset: offset, Vectors: batch}
+		if err := c.do(ctx, http.MethodPost, "/v1/datasets/"+created.DatasetID+"/vectors", req, &appended); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to upload vectors at offset %d: %w", offset, err)
+		}
+		offset += len(batch)
+	}
+	*datasetID = created.DatasetID
+	return cleanup, nil
+}
+
+// batchBySize splits vectors so the JSON encoding of each batch stays within MaxPayloadBytes.
+// A single vector larger than the limit is sent on its own.
+func (c *MLServiceClient) batchBySize(vectors [][]float32) ([][][]float32, error) {
+	limit := c.cfg.MaxPayloadBytes - requestOverhead
+	var batches [][][]float32
+	start, size := 0, 0
+	for i, v := range vectors {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		rowSize := len(encoded) + 1 // separating comma
+		if i > start && size+rowSize > limit {
+			batches = append(batches, vectors[start:i])
+			start, size = i, 0
+		}
+		size += rowSize
+	}
+	return append(batches, vectors[start:]), nil
+}
+
+// do sends a JSON request and decodes the JSON response into out, retrying transient failures
+func (c *MLServiceClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	backoff := c.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.doOnce(ctx, method, path, body, out)
+		if err == nil || attempt >= c.cfg.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *MLServiceClient) doOnce(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Detail: strings.TrimSpace(string(data))}
+		var errResp errorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Detail != "" {
+			apiErr.Detail = errResp.Detail
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode ml service response: %w", err)
+	}
+	return nil
+}
+
+// retryable reports whether err is worth another attempt: network failures, 429 and 5xx
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	// Transport failures from http.Client.Do are always *url.Error
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
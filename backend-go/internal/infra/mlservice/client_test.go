@@ -0,0 +1,216 @@
+package mlservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var _ ports.VectorAnalysisService = (*MLServiceClient)(nil)
+
+// standIn emulates the Python ML service contract documented in contract.go
+type standIn struct {
+	mu        sync.Mutex
+	datasets  map[string][][]float32
+	uploads   int
+	deleted   int
+	failFirst int // number of requests answered with 503 before behaving normally
+	calls     int
+}
+
+func newStandIn(t *testing.T) (*standIn, *httptest.Server) {
+	s := &standIn{datasets: make(map[string][][]float32)}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failFirst {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(errorResponse{Detail: "warming up"})
+		return
+	}
+	switch {
+	case r.URL.Path == "/v1/datasets":
+		id := "ds" + strconv.Itoa(len(s.datasets))
+		s.datasets[id] = nil
+		json.NewEncoder(w).Encode(createDatasetResponse{DatasetID: id})
+	case strings.HasSuffix(r.URL.Path, "/vectors"):
+		id := strings.Split(r.URL.Path, "/")[3]
+		var req appendVectorsRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.datasets[id] = append(s.datasets[id][:req.Offset], req.Vectors...)
+		s.uploads++
+		json.NewEncoder(w).Encode(appendVectorsResponse{Count: len(s.datasets[id])})
+	case r.Method == http.MethodDelete:
+		delete(s.datasets, strings.TrimPrefix(r.URL.Path, "/v1/datasets/"))
+		s.deleted++
+	case r.URL.Path == "/v1/reduce":
+		var req reduceRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		vectors := s.resolve(req.Vectors, req.DatasetID)
+		resp := reduceResponse{}
+		for _, v := range vectors {
+			resp.Coordinates = append(resp.Coordinates, v[:req.NComponents])
+		}
+		json.NewEncoder(w).Encode(resp)
+	case r.URL.Path == "/v1/cluster":
+		var req clusterRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		vectors := s.resolve(req.Vectors, req.DatasetID)
+		if len(vectors) == 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(errorResponse{Detail: "need at least 2 vectors"})
+			return
+		}
+		pos, neg := cluster{Label: 0}, cluster{Label: 1}
+		for i, v := range vectors {
+			if v[0] >= 0 {
+				pos.MemberIDs = append(pos.MemberIDs, strconv.Itoa(i))
+			} else {
+				neg.MemberIDs = append(neg.MemberIDs, strconv.Itoa(i))
+			}
+		}
+		if req.MinProbability > 0 {
+			// Soft mode: everything also belongs to the positive cluster with the threshold probability
+			for range pos.MemberIDs {
+				pos.Probabilities = append(pos.Probabilities, 1)
+			}
+			for _, member := range neg.MemberIDs {
+				neg.Probabilities = append(neg.Probabilities, 1-req.MinProbability)
+				pos.MemberIDs = append(pos.MemberIDs, member)
+				pos.Probabilities = append(pos.Probabilities, req.MinProbability)
+			}
+		}
+		json.NewEncoder(w).Encode(clusterResponse{Clusters: []cluster{pos, neg}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *standIn) resolve(inline [][]float32, datasetID string) [][]float32 {
+	if datasetID != "" {
+		return s.datasets[datasetID]
+	}
+	return inline
+}
+
+func newTestClient(t *testing.T, url string, maxPayload int) *MLServiceClient {
+	t.Helper()
+	client, err := NewMLServiceClient(Config{
+		BaseURL:         url,
+		MaxRetries:      2,
+		RetryBackoff:    1,
+		MaxPayloadBytes: maxPayload,
+		Dimensions:      2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func testVectors(n int) [][]float32 {
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		sign := float32(1)
+		if i%2 == 1 {
+			sign = -1
+		}
+		vectors[i] = []float32{sign * float32(i+1), float32(i), 0.5}
+	}
+	return vectors
+}
+
+func TestReduceAndClusterInline(t *testing.T) {
+	stand, srv := newStandIn(t)
+	client := newTestClient(t, srv.URL, 1<<20)
+	vectors := testVectors(4)
+
+	coords, err := client.Reduce(context.Background(), vectors)
+	if err != nil {
+		t.Fatalf("Reduce failed: %v", err)
+	}
+	if len(coords) != 4 || coords[3][0] != -4 || len(coords[3]) != 2 {
+		t.Errorf("unexpected coordinates: %v", coords)
+	}
+	clusters, err := client.Cluster(context.Background(), vectors, domain.ClusterOptions{})
+	if err != nil {
+		t.Fatalf("Cluster failed: %v", err)
+	}
+	if len(clusters) != 2 || strings.Join(clusters[0].MemberIDs, ",") != "0,2" {
+		t.Errorf("unexpected clusters: %+v", clusters)
+	}
+	soft, err := client.Cluster(context.Background(), vectors, domain.ClusterOptions{MinProbability: 0.25})
+	if err != nil {
+		t.Fatalf("soft Cluster failed: %v", err)
+	}
+	if strings.Join(soft[0].MemberIDs, ",") != "0,2,1,3" || soft[0].Probabilities[3] != 0.25 || soft[1].Probability(0) != 0.75 {
+		t.Errorf("unexpected soft clusters: %+v", soft)
+	}
+	if stand.uploads != 0 {
+		t.Errorf("small payloads should be sent inline, got %d uploads", stand.uploads)
+	}
+}
+
+func TestReduceBatchesLargePayloads(t *testing.T) {
+	stand, srv := newStandIn(t)
+	client := newTestClient(t, srv.URL, requestOverhead+40)
+	vectors := testVectors(10)
+
+	coords, err := client.Reduce(context.Background(), vectors)
+	if err != nil {
+		t.Fatalf("Reduce failed: %v", err)
+	}
+	for i, c := range coords {
+		if c[1] != float32(i) {
+			t.Fatalf("coordinate %d out of order: %v", i, c)
+		}
+	}
+	if stand.uploads < 2 {
+		t.Errorf("expected several batch uploads, got %d", stand.uploads)
+	}
+	if stand.deleted != 1 || len(stand.datasets) != 0 {
+		t.Errorf("dataset not cleaned up: deleted=%d remaining=%d", stand.deleted, len(stand.datasets))
+	}
+}
+
+func TestRetriesAndErrors(t *testing.T) {
+	stand, srv := newStandIn(t)
+	stand.failFirst = 2
+	client := newTestClient(t, srv.URL, 1<<20)
+
+	if _, err := client.Reduce(context.Background(), testVectors(3)); err != nil {
+		t.Fatalf("Reduce should succeed after retries: %v", err)
+	}
+	if stand.calls != 3 {
+		t.Errorf("calls = %d, want 3", stand.calls)
+	}
+
+	_, err := client.Cluster(context.Background(), testVectors(1), domain.ClusterOptions{})
+	var apiErr *APIError
+	if !errors.Is(err, domain.ErrVectorAnalysis) || !errors.As(err, &apiErr) || apiErr.Detail != "need at least 2 vectors" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stand.calls != 4 {
+		t.Errorf("4xx errors must not be retried, calls = %d", stand.calls)
+	}
+
+	stand.failFirst = 100
+	if _, err := client.Reduce(context.Background(), testVectors(3)); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after exhausting retries, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Package mlservice implements ports.VectorAnalysisService over HTTP against the Python ML service.
+//
+// JSON contract (all endpoints are POST unless noted, bodies are application/json):
+//
+//	/v1/reduce    {"vectors": [[float]], "n_components": 2|3}   -> {"coordinates": [[float]]}
+//	/v1/cluster   {"vectors": [[float]], "min_probability": float}
+//	              -> {"clusters": [{"label": int, "member_ids": ["0", "3"], "probabilities": [0.9, 0.4]}]}
+//
+// Rows of "coordinates" are in input order; "member_ids" are input positions as decimal strings.
+// "probabilities" is optional and parallel to "member_ids". With "min_probability" > 0 a vector
+// is listed in every cluster it belongs to with at least that probability (soft clustering);
+// otherwise each vector is listed once.
+//
+// When a request would exceed the client's payload limit, the vectors are uploaded
+// in batches to a temporary dataset first, and the same endpoints receive
+// {"dataset_id": "..."} in place of "vectors":
+//
+//	/v1/datasets                 {}                                  -> {"dataset_id": "..."}
+//	/v1/datasets/{id}/vectors    {"offset": int, "vectors": [[float]]} -> {"count": int}
+//	DELETE /v1/datasets/{id}                                          (best effort cleanup)
+//
+// Errors are reported with a non-2xx status and a FastAPI style {"detail": "..."} body.
+package mlservice
+
+type reduceRequest struct {
+	Vectors     [][]float32 `json:"vectors,omitempty"`
+	DatasetID   string      `json:"dataset_id,omitempty"`
+	NComponents int         `json:"n_components"`
+}
+
+type reduceResponse struct {
+	Coordinates [][]float32 `json:"coordinates"`
+}
+
+type clusterRequest struct {
+	Vectors        [][]float32 `json:"vectors,omitempty"`
+	DatasetID      string      `json:"dataset_id,omitempty"`
+	MinProbability float64     `json:"min_probability,omitempty"`
+}
+
+type cluster struct {
+	Label         int       `json:"label"`
+	MemberIDs     []string  `json:"member_ids"`
+	Probabilities []float64 `json:"probabilities,omitempty"`
+}
+
+type clusterResponse struct {
+	Clusters []cluster `json:"clusters"`
+}
+
+type createDatasetResponse struct {
+	DatasetID string `json:"dataset_id"`
+}
+
+type appendVectorsRequest struct {
+	Offset  int         `json:"offset"`
+	Vectors [][]float32 `json:"vectors"`
+}
+
+type appendVectorsResponse struct {
+	Count int `json:"count"`
+}
+
+type errorResponse struct {
+	Detail string `json:"detail"`
+}
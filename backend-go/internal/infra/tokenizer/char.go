@@ -0,0 +1,43 @@
+// Package tokenizer implements ports.Tokenizer: a SentencePiece-style tokenizer driven by a
+// local vocab file, and a character-based fallback for when no vocab is available.
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CharTokenizer approximates token counts without a vocabulary. Each whitespace-separated
+// word counts as one token, except that every CJK character counts on its own, since
+// Japanese and Chinese text has no spaces and model tokenizers spend roughly a token per character.
+type CharTokenizer struct{}
+
+// NewCharTokenizer creates a new character-based tokenizer
+func NewCharTokenizer() *CharTokenizer {
+	return &CharTokenizer{}
+}
+
+// CountTokens counts words, with CJK characters counted individually
+func (CharTokenizer) CountTokens(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		inRun := false
+		for _, r := range field {
+			if isCJK(r) {
+				count++
+				inRun = false
+				continue
+			}
+			// A run of non-CJK runes within the field ("Go" in "Go言語", or "。") is one token
+			if !inRun {
+				count++
+				inRun = true
+			}
+		}
+	}
+	return count
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
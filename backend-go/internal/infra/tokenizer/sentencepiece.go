@@ -0,0 +1,150 @@
+package tokenizer
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// spaceMarker is the SentencePiece meta symbol that stands for a word boundary
+const spaceMarker = "▁"
+
+// unknownScore is the score of a rune missing from the vocabulary; low enough that
+// the unknown path is only taken when nothing else covers the rune.
+const unknownScore = -100.0
+
+// SentencePieceTokenizer segments text into the pieces of a SentencePiece vocabulary using
+// Viterbi search over piece scores (unigram model). Vocabularies without scores get a uniform
+// score, which makes the search minimise the number of pieces, a close match for BPE output.
+type SentencePieceTokenizer struct {
+	scores       map[string]float64
+	maxPieceLen  int // in bytes
+	byteFallback bool
+}
+
+// NewSentencePieceTokenizer loads a vocabulary in the text format written by spm_export_vocab:
+// one piece per line, optionally followed by a tab and its log-probability score.
+// Control pieces such as <unk>, <s> and </s> are ignored; <0xNN> byte pieces enable byte fallback.
+func NewSentencePieceTokenizer(vocabPath string) (*SentencePieceTokenizer, error) {
+	f, err := os.Open(vocabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocab file: %w", err)
+	}
+	defer f.Close()
+
+	t := &SentencePieceTokenizer{scores: make(map[string]float64)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		piece, scoreText, hasScore := strings.Cut(scanner.Text(), "\t")
+		if piece == "" {
+			continue
+		}
+		score := -1.0
+		if hasScore {
+			if score, err = strconv.ParseFloat(strings.TrimSpace(scoreText), 64); err != nil {
+				return nil, fmt.Errorf("invalid score on vocab line %d: %w", line, err)
+			}
+		}
+		switch {
+		case isBytePiece(piece):
+			t.byteFallback = true
+			continue
+		case strings.HasPrefix(piece, "<") && strings.HasSuffix(piece, ">"):
+			continue
+		}
+		t.scores[piece] = score
+		if len(piece) > t.maxPieceLen {
+			t.maxPieceLen = len(piece)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocab file: %w", err)
+	}
+	if len(t.scores) == 0 {
+		return nil, fmt.Errorf("vocab file %s has no pieces", vocabPath)
+	}
+	return t, nil
+}
+
+// CountTokens returns the number of pieces text is segmented into
+func (t *SentencePieceTokenizer) CountTokens(text string) int {
+	return len(t.Tokenize(text))
+}
+
+// Tokenize segments text into vocabulary pieces. Runes missing from the vocabulary become
+// <0xNN> byte pieces when the vocabulary has them, and <unk> otherwise.
+func (t *SentencePieceTokenizer) Tokenize(text string) []string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil
+	}
+	// Whitespace is collapsed and marked like SentencePiece does with its default normaliser
+	s := spaceMarker + strings.Join(fields, spaceMarker)
+
+	// bounds are the byte offsets of rune boundaries; only those are valid cut points
+	bounds := make([]int, 0, len(s)+1)
+	for i := range s {
+		bounds = append(bounds, i)
+	}
+	bounds = append(bounds, len(s))
+
+	n := len(bounds)
+	best := make([]float64, n)
+	prev := make([]int, n) // index into bounds of the previous cut
+	for i := 1; i < n; i++ {
+		best[i] = math.Inf(-1)
+		for j := i - 1; j >= 0 && bounds[i]-bounds[j] <= t.maxPieceLen; j-- {
+			score, ok := t.scores[s[bounds[j]:bounds[i]]]
+			if ok && best[j]+score > best[i] {
+				best[i], prev[i] = best[j]+score, j
+			}
+		}
+		if math.IsInf(best[i], -1) {
+			// No piece ends here: the last rune is unknown
+			best[i], prev[i] = best[i-1]+unknownScore, i-1
+		}
+	}
+
+	var pieces []string
+	for i := n - 1; i > 0; i = prev[i] {
+		piece := s[bounds[prev[i]]:bounds[i]]
+		if _, ok := t.scores[piece]; !ok {
+			pieces = append(pieces, t.unknownPieces(piece)...)
+			continue
+		}
+		pieces = append(pieces, piece)
+	}
+	// Pieces were collected back to front
+	reverse(pieces)
+	return pieces
+}
+
+// unknownPieces returns the pieces for a single rune missing from the vocabulary,
+// in reverse order to match the back-to-front collection in Tokenize
+func (t *SentencePieceTokenizer) unknownPieces(r string) []string {
+	if !t.byteFallback {
+		return []string{"<unk>"}
+	}
+	out := make([]string, 0, utf8.UTFMax)
+	for i := len(r) - 1; i >= 0; i-- {
+		out = append(out, fmt.Sprintf("<0x%02X>", r[i]))
+	}
+	return out
+}
+
+func isBytePiece(piece string) bool {
+	return len(piece) == 6 && strings.HasPrefix(piece, "<0x") && piece[5] == '>'
+}
+
+func reverse(s []string) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,15 @@
+package tokenizer
+
+import (
+	"github.com/ran/demo/backend-go/internal/config"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// New creates the tokenizer described by cfg: a SentencePiece tokenizer when a vocab file
+// is configured, and the character-based fallback otherwise
+func New(cfg config.TokenizerConfig) (ports.Tokenizer, error) {
+	if cfg.VocabPath == "" {
+		return NewCharTokenizer(), nil
+	}
+	return NewSentencePieceTokenizer(cfg.VocabPath)
+}
@@ -0,0 +1,98 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/config"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+var (
+	_ ports.Tokenizer = (*CharTokenizer)(nil)
+	_ ports.Tokenizer = (*SentencePieceTokenizer)(nil)
+)
+
+func writeVocab(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.vocab")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write vocab: %v", err)
+	}
+	return path
+}
+
+func TestCharTokenizer(t *testing.T) {
+	tests := map[string]int{
+		"":                    0,
+		"One two three.":      3,
+		"本当ですか？":              6,
+		"Go言語 を 使う":           6,
+		"情報の構造化 with vectors": 8,
+	}
+	tok := NewCharTokenizer()
+	for text, want := range tests {
+		if got := tok.CountTokens(text); got != want {
+			t.Errorf("CountTokens(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestSentencePieceTokenizer(t *testing.T) {
+	path := writeVocab(t, "<unk>\t0\n<s>\t0\n</s>\t0\n"+
+		"▁hello\t-2\n▁hell\t-3\no\t-4\n▁world\t-2\n▁\t-5\nw\t-6\nor\t-6\nld\t-6\n"+
+		"▁情報\t-3\n情\t-5\n報\t-5\nの\t-4\n")
+	tok, err := NewSentencePieceTokenizer(path)
+	if err != nil {
+		t.Fatalf("failed to load vocab: %v", err)
+	}
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"hello   world", []string{"▁hello", "▁world"}},
+		{"hello world?", []string{"▁hello", "▁world", "<unk>"}},
+		{"情報の情報", []string{"▁情報", "の", "情", "報"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := tok.Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	if got := tok.CountTokens("hello world"); got != 2 {
+		t.Errorf("CountTokens = %d, want 2", got)
+	}
+}
+
+func TestSentencePieceByteFallback(t *testing.T) {
+	path := writeVocab(t, "<unk>\t0\n<0xE3>\t0\n<0x81>\t0\n<0x82>\t0\n▁a\t-1\n")
+	tok, err := NewSentencePieceTokenizer(path)
+	if err != nil {
+		t.Fatalf("failed to load vocab: %v", err)
+	}
+	// "あ" is E3 81 82 in UTF-8
+	want := []string{"▁a", "<0xE3>", "<0x81>", "<0x82>"}
+	if got := tok.Tokenize("aあ"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tok, err := New(config.TokenizerConfig{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := tok.(*CharTokenizer); !ok {
+		t.Errorf("expected char fallback without vocab, got %T", tok)
+	}
+	if _, err := New(config.TokenizerConfig{VocabPath: filepath.Join(t.TempDir(), "missing.vocab")}); err == nil {
+		t.Error("expected error for missing vocab file")
+	}
+	if _, err := NewSentencePieceTokenizer(writeVocab(t, "piece\tnot-a-number\n")); err == nil {
+		t.Error("expected error for malformed score")
+	}
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// canonicalPayload copies a payload into the same shapes Qdrant hands back:
+// integers become int64, floats float64, slices []interface{} and maps map[string]interface{}.
+// Keeping both backends symmetric means callers never see a difference in Meta.
+func canonicalPayload(payload map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		cv, err := canonicalValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("payload field %q: %w", k, err)
+		}
+		out[k] = cv
+	}
+	return out, nil
+}
+
+func canonicalValue(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case bool, string, int64, float64:
+		return x, nil
+	case int:
+		return int64(x), nil
+	case int32:
+		return int64(x), nil
+	case uint:
+		return int64(x), nil
+	case uint32:
+		return int64(x), nil
+	case uint64:
+		return int64(x), nil
+	case float32:
+		return float64(x), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return canonicalValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			item, err := canonicalValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", rv.Type().Key())
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			item, err := canonicalValue(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			m[iter.Key().String()] = item
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload type %T", v)
+	}
+}
+
+// canonicalFilter validates filter values and brings integers to int64
+func canonicalFilter(filter domain.PayloadFilter) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(filter))
+	for field, value := range filter {
+		switch v := value.(type) {
+		case string, bool, int64:
+			out[field] = v
+		case int:
+			out[field] = int64(v)
+		default:
+			return nil, fmt.Errorf("%w: field %q has type %T", domain.ErrUnsupportedFilter, field, value)
+		}
+	}
+	return out, nil
+}
+
+// matches reports whether payload satisfies every filter condition, following Qdrant's
+// rule that a list-valued field matches when any element matches.
+func matches(payload map[string]interface{}, filter map[string]interface{}) bool {
+	for field, want := range filter {
+		got, ok := payload[field]
+		if !ok {
+			return false
+		}
+		if list, isList := got.([]interface{}); isList {
+			found := false
+			for _, item := range list {
+				if item == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+			continue
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
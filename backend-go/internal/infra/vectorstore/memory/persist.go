@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	// Payload values are stored behind interface{}, so gob needs the composite types registered.
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// load reads a snapshot written by persist. A missing file means an empty store.
+func (s *MemoryStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	collections := make(map[string]*collection)
+	if err := gob.NewDecoder(f).Decode(&collections); err != nil {
+		return err
+	}
+	for _, c := range collections {
+		if c.Points == nil {
+			c.Points = make(map[string]point)
+		}
+	}
+	s.collections = collections
+	return nil
+}
+
+// persist atomically writes a snapshot of all collections. It must be called with s.mu held.
+func (s *MemoryStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(s.collections); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
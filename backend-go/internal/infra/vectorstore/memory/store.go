@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/qdrant"
+)
+
+// collection holds the points of one named collection. Fields are exported for gob persistence.
+type collection struct {
+	Size     uint64
+	Distance domain.Distance
+	Points   map[string]point
+}
+
+type point struct {
+	Vector  []float32
+	Payload map[string]interface{}
+}
+
+// MemoryStore is a pure-Go implementation of ports.VectorStoreService using brute-force search.
+// When created with a path, every write is persisted to that file and reloaded on start.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	path        string
+	collections map[string]*collection
+}
+
+// NewMemoryStore creates an in-memory vector store. An empty path disables persistence.
+func NewMemoryStore(path string) (*MemoryStore, error) {
+	s := &MemoryStore{path: path, collections: make(map[string]*collection)}
+	if path != "" {
+		if err := s.load(); err != nil {
+			return nil, fmt.Errorf("failed to load vector store from %q: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Close flushes the store to disk if persistence is enabled
+func (s *MemoryStore) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.persist()
+}
+
+// EnsureCollection creates the collection if it does not exist yet
+func (s *MemoryStore) EnsureCollection(ctx context.Context, name string, vectorSize uint64, distance domain.Distance) error {
+	switch distance {
+	case domain.DistanceCosine, domain.DistanceDot, domain.DistanceEuclid:
+	default:
+		return fmt.Errorf("unsupported distance %q", distance)
+	}
+	if vectorSize == 0 {
+		return fmt.Errorf("vector size must be > 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.collections[name]; ok {
+		return nil
+	}
+	s.collections[name] = &collection{Size: vectorSize, Distance: distance, Points: make(map[string]point)}
+	return s.persist()
+}
+
+// Index inserts or replaces the point with the given ID
+func (s *MemoryStore) Index(ctx context.Context, collectionName string, id string, vector []float32, meta map[string]interface{}) error {
+	if id == "" {
+		return domain.ErrEmptyID
+	}
+	payload, err := canonicalPayload(meta)
+	if err != nil {
+		return fmt.Errorf("invalid payload for point %s: %w", id, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(collectionName)
+	if err != nil {
+		return err
+	}
+	if uint64(len(vector)) != c.Size {
+		return domain.NewErrInvalidVectorSize(c.Size, uint64(len(vector)))
+	}
+	c.Points[id] = point{Vector: append([]float32(nil), vector...), Payload: payload}
+	return s.persist()
+}
+
+// UpsertPoints inserts or replaces points in one write, persisting the store once. Nothing is
+// written unless every point is valid.
+func (s *MemoryStore) UpsertPoints(ctx context.Context, collectionName string, points []qdrant.Point) error {
+	staged := make(map[string]point, len(points))
+	for _, p := range points {
+		if p.ID == "" {
+			return domain.ErrEmptyID
+		}
+		payload, err := canonicalPayload(p.Payload)
+		if err != nil {
+			return fmt.Errorf("invalid payload for point %s: %w", p.ID, err)
+		}
+		staged[p.ID] = point{Vector: append([]float32(nil), p.Vector...), Payload: payload}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(collectionName)
+	if err != nil {
+		return err
+	}
+	for _, p := range staged {
+		if uint64(len(p.Vector)) != c.Size {
+			return domain.NewErrInvalidVectorSize(c.Size, uint64(len(p.Vector)))
+		}
+	}
+	for id, p := range staged {
+		c.Points[id] = p
+	}
+	return s.persist()
+}
+
+// IndexChunks writes the chunks of a document and drops its chunks from earlier runs
+func (s *MemoryStore) IndexChunks(ctx context.Context, collectionName string, documentID string, chunks []domain.Chunk) error {
+	points, err := qdrant.ChunkPoints(chunks)
+	if err != nil {
+		return err
+	}
+	return qdrant.ReplaceDocumentPoints(ctx, s, collectionName, documentID, points)
+}
+
+// IndexSummaries writes the summary and partial summaries of a document and drops its
+// summaries from earlier runs
+func (s *MemoryStore) IndexSummaries(ctx context.Context, collectionName string, doc domain.Document, summary domain.Summary, chunkIDs []string, partials []domain.PartialSummary) error {
+	points, err := qdrant.SummaryPoints(doc, summary, chunkIDs, partials)
+	if err != nil {
+		return err
+	}
+	return qdrant.ReplaceDocumentPoints(ctx, s, collectionName, doc.ID, points)
+}
+
+// Search returns the topK points most similar to vector
+func (s *MemoryStore) Search(ctx context.Context, collectionName string, vector []float32, topK int) ([]domain.SearchResult, error) {
+	return s.SearchWithFilter(ctx, collectionName, vector, topK, nil)
+}
+
+// SearchWithFilter is Search restricted to points whose payload matches filter
+func (s *MemoryStore) SearchWithFilter(ctx context.Context, collectionName string, vector []float32, topK int, filter domain.PayloadFilter) ([]domain.SearchResult, error) {
+	if topK <= 0 {
+		return nil, fmt.Errorf("topK must be > 0")
+	}
+	conditions, err := canonicalFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, err := s.collection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(vector)) != c.Size {
+		return nil, domain.NewErrInvalidVectorSize(c.Size, uint64(len(vector)))
+	}
+
+	results := make([]domain.SearchResult, 0, len(c.Points))
+	for id, p := range c.Points {
+		if !matches(p.Payload, conditions) {
+			continue
+		}
+		results = append(results, domain.SearchResult{
+			ID:    id,
+			Score: score(c.Distance, vector, p.Vector),
+			Meta:  copyPayload(p.Payload),
+		})
+	}
+	// Euclid scores are distances, so smaller is better; ties are broken by ID for stable output.
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			if c.Distance == domain.DistanceEuclid {
+				return results[i].Score < results[j].Score
+			}
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// Get looks up a point by ID. The returned result has a zero Score.
+func (s *MemoryStore) Get(ctx context.Context, collectionName string, id string) (domain.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, err := s.collection(collectionName)
+	if err != nil {
+		return domain.SearchResult{}, err
+	}
+	p, ok := c.Points[id]
+	if !ok {
+		return domain.SearchResult{}, fmt.Errorf("%w: %s", domain.ErrPointNotFound, id)
+	}
+	return domain.SearchResult{ID: id, Meta: copyPayload(p.Payload)}, nil
+}
+
+// Delete removes points by ID; unknown IDs are ignored
+func (s *MemoryStore) Delete(ctx context.Context, collectionName string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(collectionName)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(c.Points, id)
+	}
+	return s.persist()
+}
+
+// DeleteByDocument removes every point whose "documentId" payload field equals documentID,
+// except the points whose IDs are listed in keep
+func (s *MemoryStore) DeleteByDocument(ctx context.Context, collectionName string, documentID string, keep ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(collectionName)
+	if err != nil {
+		return err
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, id := range keep {
+		kept[id] = true
+	}
+	cond := map[string]interface{}{"documentId": documentID}
+	for id, p := range c.Points {
+		if !kept[id] && matches(p.Payload, cond) {
+			delete(c.Points, id)
+		}
+	}
+	return s.persist()
+}
+
+// DeleteCollection drops a collection and its points; an unknown collection is ignored
+func (s *MemoryStore) DeleteCollection(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.collections[name]; !ok {
+		return nil
+	}
+	delete(s.collections, name)
+	return s.persist()
+}
+
+// collection must be called with s.mu held
+func (s *MemoryStore) collection(name string) (*collection, error) {
+	c, ok := s.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCollectionNotFound, name)
+	}
+	return c, nil
+}
+
+func score(distance domain.Distance, a, b []float32) float64 {
+	var dot, normA, normB, sq float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		sq += (x - y) * (x - y)
+	}
+	switch distance {
+	case domain.DistanceDot:
+		return dot
+	case domain.DistanceEuclid:
+		return math.Sqrt(sq)
+	default:
+		if normA == 0 || normB == 0 {
+			return 0
+		}
+		return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	}
+}
+
+// copyPayload returns a copy of the top level of a payload so callers cannot mutate stored points
+func copyPayload(payload map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/qdrant"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/vectorstoretest"
+)
+
+var _ ports.VectorStoreService = (*MemoryStore)(nil)
+
+func searchIDs(t *testing.T, s *MemoryStore, collection string, vector []float32) []string {
+	t.Helper()
+	results, err := s.Search(context.Background(), collection, vector, 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestMemoryStoreDistances(t *testing.T) {
+	ctx := context.Background()
+	s, _ := NewMemoryStore("")
+	points := map[string][]float32{
+		"a": {1, 0},
+		"b": {10, 1},
+		"c": {-1, 0},
+	}
+	tests := []struct {
+		distance domain.Distance
+		want     []string
+	}{
+		{domain.DistanceCosine, []string{"a", "b", "c"}},
+		{domain.DistanceDot, []string{"b", "a", "c"}},
+		{domain.DistanceEuclid, []string{"a", "c", "b"}},
+	}
+	for _, tt := range tests {
+		name := string(tt.distance)
+		if err := s.EnsureCollection(ctx, name, 2, tt.distance); err != nil {
+			t.Fatalf("EnsureCollection failed: %v", err)
+		}
+		for id, v := range points {
+			if err := s.Index(ctx, name, id, v, nil); err != nil {
+				t.Fatalf("Index failed: %v", err)
+			}
+		}
+		if got := searchIDs(t, s, name, []float32{1, 0}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s order = %v, want %v", name, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryStorePersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.gob")
+	s, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatalf("NewMemoryStore failed: %v", err)
+	}
+	s.EnsureCollection(ctx, "chunks", 2, domain.DistanceCosine)
+	meta := map[string]interface{}{"documentId": "doc1", "clusterIds": []int{1, 2}, "position": []float64{0.5, 1}, "note": nil}
+	if err := s.Index(ctx, "chunks", "doc1_0", []float32{1, 2}, meta); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reloaded, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	got, err := reloaded.Get(ctx, "chunks", "doc1_0")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	want := map[string]interface{}{"documentId": "doc1", "clusterIds": []interface{}{int64(1), int64(2)}, "position": []interface{}{0.5, 1.0}, "note": nil}
+	if !reflect.DeepEqual(got.Meta, want) {
+		t.Errorf("reloaded meta = %#v", got.Meta)
+	}
+	if err := reloaded.Index(ctx, "chunks", "x", []float32{1}, nil); !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("reloaded collection lost its size: %v", err)
+	}
+}
+
+func TestMemoryStoreUpsertPoints(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.gob")
+	s, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatalf("NewMemoryStore failed: %v", err)
+	}
+	if err := s.EnsureCollection(ctx, "chunks", 2, domain.DistanceCosine); err != nil {
+		t.Fatalf("EnsureCollection failed: %v", err)
+	}
+	points := []qdrant.Point{
+		{ID: "a", Vector: []float32{1, 0}, Payload: map[string]interface{}{"documentId": "doc"}},
+		{ID: "b", Vector: []float32{0, 1}},
+	}
+	if err := s.UpsertPoints(ctx, "chunks", points); err != nil {
+		t.Fatalf("UpsertPoints failed: %v", err)
+	}
+
+	bad := []qdrant.Point{{ID: "c", Vector: []float32{1, 1}}, {ID: "d", Vector: []float32{1}}}
+	if err := s.UpsertPoints(ctx, "chunks", bad); !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("expected a dimension error, got %v", err)
+	}
+
+	reloaded, err := NewMemoryStore(path)
+	if err != nil {
+		t.Fatalf("reloading failed: %v", err)
+	}
+	if got := searchIDs(t, reloaded, "chunks", []float32{1, 0}); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("reloaded points = %v; a failed batch must write nothing", got)
+	}
+}
+
+func TestMemoryStoreContract(t *testing.T) {
+	s, err := NewMemoryStore(filepath.Join(t.TempDir(), "vectors.gob"))
+	if err != nil {
+		t.Fatalf("NewMemoryStore failed: %v", err)
+	}
+	vectorstoretest.Run(t, func(t *testing.T) ports.VectorStoreService { return s })
+	if len(s.collections) != 0 {
+		t.Errorf("the suite left %d collections behind", len(s.collections))
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	sdk "github.com/qdrant/go-client/qdrant"
-	"github.com/ran/demo/backend-go/internal/domain/models"
+	"github.com/ran/demo/backend-go/internal/domain"
 )
 
 // QdrantClient wraps the Qdrant SDK GrpcClient for our specific needs
@@ -24,8 +25,14 @@ func NewQdrantClient(endpoint, apiKey string) (*QdrantClient, error) {
 	if endpoint == "" {
 		return nil, errors.New("qdrant endpoint is required")
 	}
+	// An explicit "http://" scheme opts out of TLS, e.g. for a local Qdrant container;
+	// anything else, including a bare host, uses TLS for a secure connection
+	config := &sdk.Config{UseTLS: true}
+	if strings.HasPrefix(endpoint, "http://") {
+		config.UseTLS = false
+	}
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
 	// Parse host and optional port
-	config := &sdk.Config{}
 	hostOnly, portStr, splitErr := net.SplitHostPort(endpoint)
 	if splitErr == nil {
 		// endpoint contains port
@@ -38,8 +45,6 @@ func NewQdrantClient(endpoint, apiKey string) (*QdrantClient, error) {
 	} else {
 		config.Host = endpoint
 	}
-	// Always use TLS for secure connection
-	config.UseTLS = true
 	// Set API key if provided
 	if apiKey != "" {
 		config.APIKey = apiKey
@@ -60,7 +65,11 @@ func (q *QdrantClient) Close() error {
 }
 
 // EnsureCollection ensures a collection exists with the specified parameters
-func (q *QdrantClient) EnsureCollection(ctx context.Context, collectionName string, vectorSize uint64, distance sdk.Distance) error {
+func (q *QdrantClient) EnsureCollection(ctx context.Context, collectionName string, vectorSize uint64, distance domain.Distance) error {
+	sdkDistance, err := toSDKDistance(distance)
+	if err != nil {
+		return err
+	}
 	collections := q.grpcClient.Collections()
 	existsResp, err := collections.CollectionExists(ctx, &sdk.CollectionExistsRequest{
 		CollectionName: collectionName,
@@ -75,8 +84,27 @@ func (q *QdrantClient) EnsureCollection(ctx context.Context, collectionName stri
 		CollectionName: collectionName,
 		VectorsConfig: sdk.NewVectorsConfig(&sdk.VectorParams{
 			Size:     vectorSize,
-			Distance: distance,
+			Distance: sdkDistance,
 		}),
 	})
 	return err
 }
+
+// DeleteCollection drops a collection and its points; an unknown collection is ignored
+func (q *QdrantClient) DeleteCollection(ctx context.Context, collectionName string) error {
+	_, err := q.grpcClient.Collections().Delete(ctx, &sdk.DeleteCollection{CollectionName: collectionName})
+	return err
+}
+
+func toSDKDistance(distance domain.Distance) (sdk.Distance, error) {
+	switch distance {
+	case domain.DistanceCosine:
+		return sdk.Distance_Cosine, nil
+	case domain.DistanceDot:
+		return sdk.Distance_Dot, nil
+	case domain.DistanceEuclid:
+		return sdk.Distance_Euclid, nil
+	default:
+		return sdk.Distance_UnknownDistance, fmt.Errorf("unsupported distance %q", distance)
+	}
+}
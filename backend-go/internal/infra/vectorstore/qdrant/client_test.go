@@ -4,9 +4,10 @@ import (
 	"context"
 	"testing"
 
-	sdk "github.com/qdrant/go-client/qdrant"
 	"github.com/ran/demo/backend-go/internal/config"
-	"github.com/ran/demo/backend-go/internal/domain/models"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/vectorstoretest"
 )
 
 // getQdrantEnv loads QDRANT_HOST and QDRANT_API_KEY
@@ -40,8 +41,28 @@ func TestQdrantEnsureCollection(t *testing.T) {
 	collectionName := "midjourney"
 	// New collection
 	// collectionName := "cascade_test_collection"
-	err = client.EnsureCollection(context.Background(), collectionName, 512, sdk.Distance_Cosine)
+	err = client.EnsureCollection(context.Background(), collectionName, 512, domain.DistanceCosine)
 	if err != nil {
 		t.Fatalf("EnsureCollection failed: %v", err)
 	}
 }
+
+// TestQdrantContract runs the shared vector store suite against a disposable local Qdrant, e.g.
+//
+//	docker run --rm -p 6334:6334 qdrant/qdrant
+//	QDRANT_TEST_ENDPOINT=http://localhost:6334 go test ./internal/infra/vectorstore/...
+func TestQdrantContract(t *testing.T) {
+	endpoint := config.GetEnv("QDRANT_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("QDRANT_TEST_ENDPOINT must be set to run the contract suite against Qdrant")
+	}
+	client, err := NewQdrantClient(endpoint, config.GetEnv("QDRANT_TEST_API_KEY"))
+	if err != nil {
+		t.Fatalf("failed to create Qdrant client: %v", err)
+	}
+	defer client.Close()
+
+	vectorstoretest.Run(t, func(t *testing.T) ports.VectorStoreService {
+		return client
+	})
+}
@@ -0,0 +1,51 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// documentPointStore is a vector store that writes points in batches and drops a document's points
+type documentPointStore interface {
+	UpsertPoints(ctx context.Context, collection string, points []Point) error
+	DeleteByDocument(ctx context.Context, collection string, documentID string, keep ...string) error
+}
+
+// ReplaceDocumentPoints writes points, all of document documentID, to collection, then drops the
+// document's points there that are not among them. Point IDs are stable, so re-ingestion
+// overwrites points in place and only drops those left over from a run that produced more; a
+// failed write leaves the earlier points in place.
+func ReplaceDocumentPoints(ctx context.Context, store documentPointStore, collection, documentID string, points []Point) error {
+	if err := store.UpsertPoints(ctx, collection, points); err != nil {
+		return err
+	}
+	ids := make([]string, len(points))
+	for i, p := range points {
+		ids[i] = p.ID
+	}
+	if err := store.DeleteByDocument(ctx, collection, documentID, ids...); err != nil {
+		return fmt.Errorf("failed to clear stale points: %w", err)
+	}
+	return nil
+}
+
+// IndexChunks writes the chunks of a document and drops its chunks from earlier runs
+func (q *QdrantClient) IndexChunks(ctx context.Context, collection string, documentID string, chunks []domain.Chunk) error {
+	points, err := ChunkPoints(chunks)
+	if err != nil {
+		return err
+	}
+	return ReplaceDocumentPoints(ctx, q, collection, documentID, points)
+}
+
+// IndexSummaries writes the summary and partial summaries of a document and drops its
+// summaries from earlier runs
+func (q *QdrantClient) IndexSummaries(ctx context.Context, collection string, doc domain.Document, summary domain.Summary, chunkIDs []string, partials []domain.PartialSummary) error {
+	points, err := SummaryPoints(doc, summary, chunkIDs, partials)
+	if err != nil {
+		return err
+	}
+	return ReplaceDocumentPoints(ctx, q, collection, doc.ID, points)
+}
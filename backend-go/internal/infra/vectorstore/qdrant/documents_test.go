@@ -0,0 +1,57 @@
+package qdrant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// recordingPointStore records the writes and deletions it is asked for, failing writes with err
+type recordingPointStore struct {
+	err   error
+	calls []string
+	keep  []string
+}
+
+func (s *recordingPointStore) UpsertPoints(ctx context.Context, collection string, points []Point) error {
+	s.calls = append(s.calls, "upsert")
+	return s.err
+}
+
+func (s *recordingPointStore) DeleteByDocument(ctx context.Context, collection string, documentID string, keep ...string) error {
+	s.calls = append(s.calls, "delete")
+	s.keep = keep
+	return nil
+}
+
+func TestReplaceDocumentPoints(t *testing.T) {
+	points, err := ChunkPoints([]domain.Chunk{
+		{ID: "doc1_0", DocumentID: "doc1", Embedding: []float32{1}, Coord2D: &[2]float32{1, 2}},
+		{ID: "doc1_1", DocumentID: "doc1", Embedding: []float32{2}, Coord3D: &[3]float32{1, 2, 3}},
+	})
+	if err != nil {
+		t.Fatalf("ChunkPoints failed: %v", err)
+	}
+	if got := points[1].Payload["position"]; !reflect.DeepEqual(got, []float64{1, 2, 3}) {
+		t.Errorf("position = %v, want the 3D coordinates", got)
+	}
+
+	store := &recordingPointStore{}
+	if err := ReplaceDocumentPoints(context.Background(), store, "chunks", "doc1", points); err != nil {
+		t.Fatalf("ReplaceDocumentPoints failed: %v", err)
+	}
+	if !reflect.DeepEqual(store.calls, []string{"upsert", "delete"}) || !reflect.DeepEqual(store.keep, []string{"doc1_0", "doc1_1"}) {
+		t.Errorf("calls = %v keeping %v; want an upsert, then a delete keeping the written points", store.calls, store.keep)
+	}
+
+	failing := &recordingPointStore{err: errors.New("disk full")}
+	if err := ReplaceDocumentPoints(context.Background(), failing, "chunks", "doc1", points); err == nil {
+		t.Fatal("expected the failed write to fail")
+	}
+	if !reflect.DeepEqual(failing.calls, []string{"upsert"}) {
+		t.Errorf("calls = %v; a failed write must keep the earlier points", failing.calls)
+	}
+}
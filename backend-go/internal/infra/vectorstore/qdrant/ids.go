@@ -0,0 +1,50 @@
+package qdrant
+
+import (
+	"crypto/sha1"
+	"fmt"
+
+	sdk "github.com/qdrant/go-client/qdrant"
+)
+
+// PayloadIDKey is the payload field holding the domain ID a point was derived from
+const PayloadIDKey = "originalId"
+
+// pointIDNamespace is the UUIDv5 namespace for point IDs. Changing it re-keys every stored point.
+var pointIDNamespace = [16]byte{
+	0x6f, 0x1c, 0x8e, 0x2a, 0x53, 0x0b, 0x4d, 0x7e,
+	0x9a, 0x61, 0x2f, 0xc4, 0x38, 0x95, 0xd0, 0x17,
+}
+
+// PointUUID derives the Qdrant point ID for a domain ID such as "<docID>_3" or "<docID>_summary".
+// The mapping is a UUIDv5, so indexing the same domain ID twice overwrites the same point.
+func PointUUID(domainID string) string {
+	return uuidV5(pointIDNamespace, domainID)
+}
+
+func toPointID(domainID string) *sdk.PointId {
+	return sdk.NewID(PointUUID(domainID))
+}
+
+// domainIDFromPoint recovers the domain ID from a point's payload, falling back to the raw point ID
+func domainIDFromPoint(id *sdk.PointId, payload map[string]*sdk.Value) string {
+	if v, ok := payload[PayloadIDKey]; ok && v.GetStringValue() != "" {
+		return v.GetStringValue()
+	}
+	if uuid := id.GetUuid(); uuid != "" {
+		return uuid
+	}
+	return fmt.Sprintf("%d", id.GetNum())
+}
+
+// uuidV5 implements RFC 4122 name-based UUIDs using SHA-1
+func uuidV5(namespace [16]byte, name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,46 @@
+package qdrant
+
+import (
+	"regexp"
+	"testing"
+
+	sdk "github.com/qdrant/go-client/qdrant"
+)
+
+func TestUUIDv5(t *testing.T) {
+	// RFC 4122 DNS namespace, known value for "python.org"
+	dns := [16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	if got := uuidV5(dns, "python.org"); got != "886313e1-3b8a-5372-9b90-0c9aee199e5d" {
+		t.Errorf("uuidV5 = %s", got)
+	}
+}
+
+func TestPointUUID(t *testing.T) {
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a := PointUUID("doc1_3")
+	if !uuidRe.MatchString(a) {
+		t.Fatalf("not a v5 UUID: %s", a)
+	}
+	if PointUUID("doc1_3") != a {
+		t.Error("PointUUID is not deterministic")
+	}
+	if PointUUID("doc1_summary") == a || PointUUID("doc1_30") == a {
+		t.Error("distinct domain IDs collided")
+	}
+}
+
+func TestPointStructKeepsDomainID(t *testing.T) {
+	ps, err := toPointStruct(Point{ID: "doc1_3", Vector: []float32{1}, Payload: map[string]interface{}{"chunkId": "doc1_3"}})
+	if err != nil {
+		t.Fatalf("toPointStruct failed: %v", err)
+	}
+	if ps.GetId().GetUuid() != PointUUID("doc1_3") {
+		t.Errorf("point id = %v", ps.GetId())
+	}
+	if got := domainIDFromPoint(ps.GetId(), ps.GetPayload()); got != "doc1_3" {
+		t.Errorf("domainIDFromPoint = %q", got)
+	}
+	if got := domainIDFromPoint(sdk.NewIDNum(42), nil); got != "42" {
+		t.Errorf("fallback id = %q", got)
+	}
+}
@@ -2,12 +2,14 @@ package qdrant
 
 import (
 	"fmt"
+	"strconv"
 
-	"github.com/ran/demo/backend-go/internal/domain/models"
+	"github.com/ran/demo/backend-go/internal/domain"
 )
 
 // Point represents a Qdrant point payload in our domain
-// ID is the unique point identifier; Payload contains vector metadata
+// ID is the domain identifier (e.g. a chunk ID); QdrantClient stores it under PointUUID(ID)
+// and keeps the original in the payload. Payload contains vector metadata
 type Point struct {
 	ID      string                 `json:"id"`
 	Vector  []float32              `json:"vector"`
@@ -24,32 +26,44 @@ type DocumentMeta struct {
 	ChunkIds         []string  `json:"chunkIds"`
 }
 
-// MapToQdrantPoints merges chunks with embeddings and coords into Point structs, including cluster IDs and keywords
-func MapToQdrantPoints(chunks []models.Chunk, embeddings [][]float32, coords [][]float64) ([]Point, error) {
+// MapToQdrantPoints merges chunks with embeddings and coords into Point structs, including the chunk's tree level
+// and parent chunk ID (empty at the top level), cluster IDs,
+// their membership probabilities (parallel to clusterIds), keywords, the chunk's byte and line span in
+// the source document, and the [start, end) byte range of text repeated from the previous chunk when
+// chunks overlap
+func MapToQdrantPoints(chunks []domain.Chunk, embeddings [][]float32, coords [][]float64) ([]Point, error) {
 	n := len(chunks)
 	if len(embeddings) != n || len(coords) != n {
 		return nil, fmt.Errorf("length mismatch: chunks=%d, embeddings=%d, coords=%d", n, len(embeddings), len(coords))
 	}
 	points := make([]Point, n)
 	for i, c := range chunks {
-		points[i] = Point{
-			ID:     c.ID,
-			Vector: embeddings[i],
-			Payload: map[string]interface{}{
-				"documentId": c.DocumentID,
-				"chunkId":    c.ID,
-				"text":       c.Text,
-				"position":   coords[i],
-				"clusterIds": c.ClusterIDs,
-				"keywords":   c.Keywords,
-			},
+		payload := map[string]interface{}{
+			"documentId":           c.DocumentID,
+			"chunkId":              c.ID,
+			"text":                 c.Text,
+			"position":             coords[i],
+			"clusterIds":           c.ClusterIDs,
+			"clusterProbabilities": c.ClusterProbabilities,
+			"keywords":             c.Keywords,
+			"headingPath":          c.HeadingPath,
+			"level":                string(c.Level),
+			"parentId":             c.ParentID,
+			"startByte":            c.Span.StartByte,
+			"endByte":              c.Span.EndByte,
+			"startLine":            c.Span.StartLine,
+			"endLine":              c.Span.EndLine,
 		}
+		if c.Overlap != nil {
+			payload["overlapRange"] = []int{c.Overlap.Start, c.Overlap.End}
+		}
+		points[i] = Point{ID: c.ID, Vector: embeddings[i], Payload: payload}
 	}
 	return points, nil
 }
 
 // MapDocMeta creates DocumentMeta for overall document summary
-func MapDocMeta(doc models.Document, summary models.Summary, chunkIDs []string, summaryPosition []float64, summaryClusterId string) (DocumentMeta, error) {
+func MapDocMeta(doc domain.Document, summary domain.Summary, chunkIDs []string, summaryPosition []float64, summaryClusterId string) (DocumentMeta, error) {
 	if err := doc.Validate(); err != nil {
 		return DocumentMeta{}, fmt.Errorf("invalid document %s: %w", doc.ID, err)
 	}
@@ -65,3 +79,67 @@ func MapDocMeta(doc models.Document, summary models.Summary, chunkIDs []string,
 		ChunkIds:         chunkIDs,
 	}, nil
 }
+
+// Payload converts DocumentMeta into a Qdrant point payload
+func (m DocumentMeta) Payload() map[string]interface{} {
+	return map[string]interface{}{
+		"documentId":       m.DocumentId,
+		"fileName":         m.FileName,
+		"summaryText":      m.SummaryText,
+		"summaryPosition":  m.SummaryPosition,
+		"summaryClusterId": m.SummaryClusterId,
+		"chunkIds":         m.ChunkIds,
+	}
+}
+
+// ChunkPoints maps chunks to points carrying their embeddings, with the chunks' 3D coordinates,
+// or 2D ones when they have no third, as positions
+func ChunkPoints(chunks []domain.Chunk) ([]Point, error) {
+	embeddings := make([][]float32, len(chunks))
+	coords := make([][]float64, len(chunks))
+	for i, c := range chunks {
+		embeddings[i] = c.Embedding
+		coords[i] = position(c.Coord2D, c.Coord3D)
+	}
+	return MapToQdrantPoints(chunks, embeddings, coords)
+}
+
+// SummaryPoints maps the summary of doc, listing its chunkIDs, and the document's partial
+// summaries to points carrying their embeddings
+func SummaryPoints(doc domain.Document, summary domain.Summary, chunkIDs []string, partials []domain.PartialSummary) ([]Point, error) {
+	clusterID := ""
+	if summary.ClusterID != nil {
+		clusterID = strconv.Itoa(*summary.ClusterID)
+	}
+	meta, err := MapDocMeta(doc, summary, chunkIDs, position(summary.Coord2D, summary.Coord3D), clusterID)
+	if err != nil {
+		return nil, err
+	}
+	points := []Point{{ID: summary.ID, Vector: summary.Embedding, Payload: meta.Payload()}}
+	for _, p := range partials {
+		points = append(points, Point{ID: p.ID, Vector: p.Embedding, Payload: map[string]interface{}{
+			"documentId": doc.ID,
+			"text":       p.Text,
+			"level":      p.Level,
+			"parentId":   p.ParentID,
+			"sourceIds":  p.SourceIDs,
+		}})
+	}
+	return points, nil
+}
+
+// position returns the coordinates of a point, 3D when known; nil when it has none
+func position(coord2D *[2]float32, coord3D *[3]float32) []float64 {
+	var v []float32
+	switch {
+	case coord3D != nil:
+		v = coord3D[:]
+	case coord2D != nil:
+		v = coord2D[:]
+	}
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x)
+	}
+	return out
+}
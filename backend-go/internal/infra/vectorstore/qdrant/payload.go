@@ -0,0 +1,100 @@
+package qdrant
+
+import (
+	"fmt"
+	"reflect"
+
+	sdk "github.com/qdrant/go-client/qdrant"
+)
+
+// toValueMap converts a domain payload into Qdrant values.
+// Typed slices, arrays and maps (e.g. []string, []float64, *[2]float32) are normalised first,
+// since the SDK only accepts []interface{} and map[string]interface{}.
+func toValueMap(payload map[string]interface{}) (map[string]*sdk.Value, error) {
+	normalized := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		nv, err := normalizeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("payload field %q: %w", k, err)
+		}
+		normalized[k] = nv
+	}
+	return sdk.TryValueMap(normalized)
+}
+
+func normalizeValue(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, bool, int, int32, int64, uint, uint32, uint64, float32, float64, string, []byte:
+		return v, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return normalizeValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return []interface{}{}, nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			item, err := normalizeValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", rv.Type().Key())
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			item, err := normalizeValue(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			m[iter.Key().String()] = item
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload type %T", v)
+	}
+}
+
+// fromValueMap converts a Qdrant payload back into plain Go values.
+// Integers come back as int64, doubles as float64, lists as []interface{} and structs as map[string]interface{}.
+func fromValueMap(payload map[string]*sdk.Value) map[string]interface{} {
+	meta := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		meta[k] = fromValue(v)
+	}
+	return meta
+}
+
+func fromValue(v *sdk.Value) interface{} {
+	switch kind := v.GetKind().(type) {
+	case *sdk.Value_BoolValue:
+		return kind.BoolValue
+	case *sdk.Value_IntegerValue:
+		return kind.IntegerValue
+	case *sdk.Value_DoubleValue:
+		return kind.DoubleValue
+	case *sdk.Value_StringValue:
+		return kind.StringValue
+	case *sdk.Value_ListValue:
+		list := make([]interface{}, len(kind.ListValue.GetValues()))
+		for i, item := range kind.ListValue.GetValues() {
+			list[i] = fromValue(item)
+		}
+		return list
+	case *sdk.Value_StructValue:
+		return fromValueMap(kind.StructValue.GetFields())
+	default:
+		return nil
+	}
+}
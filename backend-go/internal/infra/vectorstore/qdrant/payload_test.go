@@ -0,0 +1,65 @@
+package qdrant
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ ports.VectorStoreService = (*QdrantClient)(nil)
+
+func TestPayloadRoundTrip(t *testing.T) {
+	coord := [2]float32{1.5, -2}
+	payload := map[string]interface{}{
+		"documentId": "doc1",
+		"position":   []float64{0.5, 1},
+		"clusterIds": []int{3, 4},
+		"keywords":   []string{"a", "b"},
+		"coord":      &coord,
+		"empty":      []string(nil),
+		"nested":     map[string][]string{"k": {"v"}},
+	}
+	values, err := toValueMap(payload)
+	if err != nil {
+		t.Fatalf("toValueMap failed: %v", err)
+	}
+	got := fromValueMap(values)
+	want := map[string]interface{}{
+		"documentId": "doc1",
+		"position":   []interface{}{0.5, 1.0},
+		"clusterIds": []interface{}{int64(3), int64(4)},
+		"keywords":   []interface{}{"a", "b"},
+		"coord":      []interface{}{1.5, -2.0},
+		"empty":      []interface{}{},
+		"nested":     map[string]interface{}{"k": []interface{}{"v"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got  %#v\n want %#v", got, want)
+	}
+}
+
+func TestPayloadRejectsUnsupportedTypes(t *testing.T) {
+	if _, err := toValueMap(map[string]interface{}{"ch": make(chan int)}); err == nil {
+		t.Error("expected error for channel payload")
+	}
+}
+
+func TestMapError(t *testing.T) {
+	err := mapError("chunks", status.Error(codes.NotFound, "Not found: Collection `chunks` doesn't exist!"))
+	if !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("expected ErrCollectionNotFound, got %v", err)
+	}
+	err = mapError("chunks", status.Error(codes.InvalidArgument, "Wrong input: Vector dimension error: expected dim: 4, got 3"))
+	if !errors.Is(err, domain.ErrInvalidVectorSize) || err.Error() != "invalid vector size: expected 4, got 3" {
+		t.Errorf("expected ErrInvalidVectorSize, got %v", err)
+	}
+	other := errors.New("boom")
+	if mapError("chunks", other) != other {
+		t.Error("non-gRPC errors should pass through")
+	}
+}
@@ -0,0 +1,213 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sdk "github.com/qdrant/go-client/qdrant"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// upsertBatchSize is the maximum number of points sent in a single upsert request
+const upsertBatchSize = 256
+
+// dimensionErrRe extracts sizes from Qdrant's "Vector dimension error: expected dim: X, got Y" message
+var dimensionErrRe = regexp.MustCompile(`expected dim: (\d+), got (\d+)`)
+
+// Index upserts a single vector with its metadata
+func (q *QdrantClient) Index(ctx context.Context, collection string, id string, vector []float32, meta map[string]interface{}) error {
+	return q.UpsertPoints(ctx, collection, []Point{{ID: id, Vector: vector, Payload: meta}})
+}
+
+// UpsertPoints writes points into a collection in batches of upsertBatchSize, waiting for each batch to be applied
+func (q *QdrantClient) UpsertPoints(ctx context.Context, collection string, points []Point) error {
+	wait := true
+	for start := 0; start < len(points); start += upsertBatchSize {
+		end := min(start+upsertBatchSize, len(points))
+		structs := make([]*sdk.PointStruct, 0, end-start)
+		for _, p := range points[start:end] {
+			ps, err := toPointStruct(p)
+			if err != nil {
+				return err
+			}
+			structs = append(structs, ps)
+		}
+		_, err := q.grpcClient.Points().Upsert(ctx, &sdk.UpsertPoints{
+			CollectionName: collection,
+			Wait:           &wait,
+			Points:         structs,
+		})
+		if err != nil {
+			return mapError(collection, err)
+		}
+	}
+	return nil
+}
+
+// Search returns the topK points most similar to vector, with their payload as Meta
+func (q *QdrantClient) Search(ctx context.Context, collection string, vector []float32, topK int) ([]domain.SearchResult, error) {
+	return q.SearchWithFilter(ctx, collection, vector, topK, nil)
+}
+
+// SearchWithFilter is Search restricted to points whose payload matches filter
+func (q *QdrantClient) SearchWithFilter(ctx context.Context, collection string, vector []float32, topK int, filter domain.PayloadFilter) ([]domain.SearchResult, error) {
+	if topK <= 0 {
+		return nil, fmt.Errorf("topK must be > 0")
+	}
+	sdkFilter, err := toSDKFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	limit := uint64(topK)
+	resp, err := q.grpcClient.Points().Query(ctx, &sdk.QueryPoints{
+		CollectionName: collection,
+		Query:          sdk.NewQueryDense(vector),
+		Filter:         sdkFilter,
+		Limit:          &limit,
+		WithPayload:    sdk.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, mapError(collection, err)
+	}
+	results := make([]domain.SearchResult, 0, len(resp.GetResult()))
+	for _, sp := range resp.GetResult() {
+		results = append(results, domain.SearchResult{
+			ID:    domainIDFromPoint(sp.GetId(), sp.GetPayload()),
+			Score: float64(sp.GetScore()),
+			Meta:  fromValueMap(sp.GetPayload()),
+		})
+	}
+	return results, nil
+}
+
+// Get looks up a single point by its domain ID. The returned result has a zero Score.
+func (q *QdrantClient) Get(ctx context.Context, collection string, id string) (domain.SearchResult, error) {
+	resp, err := q.grpcClient.Points().Get(ctx, &sdk.GetPoints{
+		CollectionName: collection,
+		Ids:            []*sdk.PointId{toPointID(id)},
+		WithPayload:    sdk.NewWithPayload(true),
+	})
+	if err != nil {
+		return domain.SearchResult{}, mapError(collection, err)
+	}
+	if len(resp.GetResult()) == 0 {
+		return domain.SearchResult{}, fmt.Errorf("%w: %s", domain.ErrPointNotFound, id)
+	}
+	rp := resp.GetResult()[0]
+	return domain.SearchResult{
+		ID:   domainIDFromPoint(rp.GetId(), rp.GetPayload()),
+		Meta: fromValueMap(rp.GetPayload()),
+	}, nil
+}
+
+// Delete removes the points with the given domain IDs. Unknown IDs are ignored.
+func (q *QdrantClient) Delete(ctx context.Context, collection string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	pointIDs := make([]*sdk.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = toPointID(id)
+	}
+	return q.deletePoints(ctx, collection, sdk.NewPointsSelectorIDs(pointIDs))
+}
+
+// DeleteByDocument removes every point whose payload belongs to the given document,
+// except the points whose domain IDs are listed in keep
+func (q *QdrantClient) DeleteByDocument(ctx context.Context, collection string, documentID string, keep ...string) error {
+	filter := &sdk.Filter{
+		Must: []*sdk.Condition{sdk.NewMatch("documentId", documentID)},
+	}
+	if len(keep) > 0 {
+		pointIDs := make([]*sdk.PointId, len(keep))
+		for i, id := range keep {
+			pointIDs[i] = toPointID(id)
+		}
+		filter.MustNot = []*sdk.Condition{sdk.NewHasID(pointIDs...)}
+	}
+	return q.deletePoints(ctx, collection, sdk.NewPointsSelectorFilter(filter))
+}
+
+func (q *QdrantClient) deletePoints(ctx context.Context, collection string, selector *sdk.PointsSelector) error {
+	wait := true
+	_, err := q.grpcClient.Points().Delete(ctx, &sdk.DeletePoints{
+		CollectionName: collection,
+		Wait:           &wait,
+		Points:         selector,
+	})
+	if err != nil {
+		return mapError(collection, err)
+	}
+	return nil
+}
+
+func toPointStruct(p Point) (*sdk.PointStruct, error) {
+	if len(p.Vector) == 0 {
+		return nil, fmt.Errorf("%w: point %s has no vector", domain.ErrInvalidEmbedding, p.ID)
+	}
+	meta := make(map[string]interface{}, len(p.Payload)+1)
+	for k, v := range p.Payload {
+		meta[k] = v
+	}
+	meta[PayloadIDKey] = p.ID
+	payload, err := toValueMap(meta)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload for point %s: %w", p.ID, err)
+	}
+	return &sdk.PointStruct{
+		Id:      toPointID(p.ID),
+		Vectors: sdk.NewVectorsDense(p.Vector),
+		Payload: payload,
+	}, nil
+}
+
+// toSDKFilter converts a payload filter into Qdrant "must" match conditions
+func toSDKFilter(filter domain.PayloadFilter) (*sdk.Filter, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+	conditions := make([]*sdk.Condition, 0, len(filter))
+	for field, value := range filter {
+		switch v := value.(type) {
+		case string:
+			conditions = append(conditions, sdk.NewMatchKeyword(field, v))
+		case bool:
+			conditions = append(conditions, sdk.NewMatchBool(field, v))
+		case int:
+			conditions = append(conditions, sdk.NewMatchInt(field, int64(v)))
+		case int64:
+			conditions = append(conditions, sdk.NewMatchInt(field, v))
+		default:
+			return nil, fmt.Errorf("%w: field %q has type %T", domain.ErrUnsupportedFilter, field, value)
+		}
+	}
+	return &sdk.Filter{Must: conditions}, nil
+}
+
+// mapError translates Qdrant gRPC errors into domain errors where possible
+func mapError(collection string, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	msg := st.Message()
+	switch st.Code() {
+	case codes.NotFound:
+		if strings.Contains(msg, "Collection") || strings.Contains(msg, "collection") {
+			return fmt.Errorf("%w: %s", domain.ErrCollectionNotFound, collection)
+		}
+		return fmt.Errorf("%w: %s", domain.ErrPointNotFound, msg)
+	case codes.InvalidArgument:
+		if m := dimensionErrRe.FindStringSubmatch(msg); m != nil {
+			expected, _ := strconv.ParseUint(m[1], 10, 64)
+			got, _ := strconv.ParseUint(m[2], 10, 64)
+			return domain.NewErrInvalidVectorSize(expected, got)
+		}
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package vectorstore
+
+import (
+	"fmt"
+
+	"github.com/ran/demo/backend-go/internal/config"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/memory"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/qdrant"
+)
+
+// Store is a vector store that holds resources to release on shutdown
+type Store interface {
+	ports.VectorStoreService
+	Close() error
+}
+
+// NewStore creates the vector store selected by cfg.Backend
+func NewStore(cfg config.VectorStoreConfig) (Store, error) {
+	switch cfg.Backend {
+	case config.VectorStoreQdrant:
+		return qdrant.NewQdrantClient(cfg.Endpoint, cfg.APIKey)
+	case config.VectorStoreMemory:
+		return memory.NewMemoryStore(cfg.Path)
+	default:
+		return nil, fmt.Errorf("unknown vector store backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,274 @@
+// Package vectorstoretest provides a conformance suite that every ports.VectorStoreService
+// implementation runs against itself, so backends stay interchangeable.
+package vectorstoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// Factory returns the store under test. It is called once per subtest; returning
+// a shared store is fine because every subtest works in its own collection.
+type Factory func(t *testing.T) ports.VectorStoreService
+
+// collectionDeleter is implemented by stores that can drop a whole collection. The suite uses it
+// to remove the collections it creates, so runs against a shared server leave nothing behind.
+type collectionDeleter interface {
+	DeleteCollection(ctx context.Context, collection string) error
+}
+
+// Run executes the contract suite against the store returned by factory
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s ports.VectorStoreService, collection string)
+	}{
+		{"EnsureCollectionIsIdempotent", testEnsureCollectionIdempotent},
+		{"IndexAndGet", testIndexAndGet},
+		{"ReindexOverwrites", testReindexOverwrites},
+		{"SearchOrdering", testSearchOrdering},
+		{"SearchEuclidOrdering", testSearchEuclidOrdering},
+		{"TopKBounds", testTopKBounds},
+		{"Filters", testFilters},
+		{"Delete", testDelete},
+		{"DimensionMismatch", testDimensionMismatch},
+		{"MissingCollection", testMissingCollection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, collection := factory(t), collectionName(tt.name)
+			if d, ok := store.(collectionDeleter); ok {
+				t.Cleanup(func() {
+					if err := d.DeleteCollection(context.Background(), collection); err != nil {
+						t.Errorf("DeleteCollection(%s) failed: %v", collection, err)
+					}
+				})
+			}
+			tt.fn(t, store, collection)
+		})
+	}
+}
+
+// collectionName returns a collection name unique to this run, so suites can share a server
+func collectionName(test string) string {
+	return fmt.Sprintf("contract_%s_%d", strings.ToLower(test), time.Now().UnixNano())
+}
+
+func ensure(t *testing.T, s ports.VectorStoreService, collection string, size uint64, distance domain.Distance) {
+	t.Helper()
+	if err := s.EnsureCollection(context.Background(), collection, size, distance); err != nil {
+		t.Fatalf("EnsureCollection(%s) failed: %v", collection, err)
+	}
+}
+
+func index(t *testing.T, s ports.VectorStoreService, collection, id string, vector []float32, meta map[string]interface{}) {
+	t.Helper()
+	if err := s.Index(context.Background(), collection, id, vector, meta); err != nil {
+		t.Fatalf("Index(%s) failed: %v", id, err)
+	}
+}
+
+func search(t *testing.T, s ports.VectorStoreService, collection string, vector []float32, topK int, filter domain.PayloadFilter) []domain.SearchResult {
+	t.Helper()
+	results, err := s.SearchWithFilter(context.Background(), collection, vector, topK, filter)
+	if err != nil {
+		t.Fatalf("SearchWithFilter failed: %v", err)
+	}
+	return results
+}
+
+func ids(results []domain.SearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.ID
+	}
+	return out
+}
+
+// assertMetaContains checks that every expected payload field came back with the canonical
+// shape: integers as int64, floats as float64, lists as []interface{}. Backends may add fields.
+func assertMetaContains(t *testing.T, got, want map[string]interface{}) {
+	t.Helper()
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("meta[%q] = %#v, want %#v", k, got[k], v)
+		}
+	}
+}
+
+func testEnsureCollectionIdempotent(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 3, domain.DistanceCosine)
+	index(t, s, collection, "a", []float32{1, 0, 0}, nil)
+	ensure(t, s, collection, 3, domain.DistanceCosine)
+	if _, err := s.Get(context.Background(), collection, "a"); err != nil {
+		t.Errorf("point lost after second EnsureCollection: %v", err)
+	}
+}
+
+func testIndexAndGet(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 3, domain.DistanceCosine)
+	index(t, s, collection, "doc1_0", []float32{1, 2, 3}, map[string]interface{}{
+		"documentId": "doc1",
+		"clusterIds": []int{1, 2},
+		"position":   []float64{0.5, -1.5},
+		"keywords":   []string{"go"},
+	})
+	got, err := s.Get(context.Background(), collection, "doc1_0")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.ID != "doc1_0" {
+		t.Errorf("ID = %q, want doc1_0", got.ID)
+	}
+	assertMetaContains(t, got.Meta, map[string]interface{}{
+		"documentId": "doc1",
+		"clusterIds": []interface{}{int64(1), int64(2)},
+		"position":   []interface{}{0.5, -1.5},
+		"keywords":   []interface{}{"go"},
+	})
+}
+
+func testReindexOverwrites(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 2, domain.DistanceCosine)
+	index(t, s, collection, "p", []float32{1, 0}, map[string]interface{}{"version": 1})
+	index(t, s, collection, "p", []float32{0, 1}, map[string]interface{}{"version": 2})
+
+	results := search(t, s, collection, []float32{0, 1}, 10, nil)
+	if len(results) != 1 {
+		t.Fatalf("got %d points after re-index, want 1", len(results))
+	}
+	if results[0].ID != "p" || math.Abs(results[0].Score-1) > 1e-4 {
+		t.Errorf("re-indexed vector not used: %+v", results[0])
+	}
+	assertMetaContains(t, results[0].Meta, map[string]interface{}{"version": int64(2)})
+}
+
+func testSearchOrdering(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 2, domain.DistanceCosine)
+	index(t, s, collection, "same", []float32{2, 0}, nil)
+	index(t, s, collection, "close", []float32{1, 1}, nil)
+	index(t, s, collection, "orthogonal", []float32{0, 3}, nil)
+	index(t, s, collection, "opposite", []float32{-1, 0}, nil)
+
+	results := search(t, s, collection, []float32{1, 0}, 10, nil)
+	if want := []string{"same", "close", "orthogonal", "opposite"}; !reflect.DeepEqual(ids(results), want) {
+		t.Fatalf("order = %v, want %v", ids(results), want)
+	}
+	wantScores := []float64{1, math.Sqrt2 / 2, 0, -1}
+	for i, r := range results {
+		if math.Abs(r.Score-wantScores[i]) > 1e-4 {
+			t.Errorf("score of %s = %f, want %f", r.ID, r.Score, wantScores[i])
+		}
+	}
+}
+
+func testSearchEuclidOrdering(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 2, domain.DistanceEuclid)
+	index(t, s, collection, "far", []float32{10, 0}, nil)
+	index(t, s, collection, "near", []float32{1, 1}, nil)
+	index(t, s, collection, "exact", []float32{1, 0}, nil)
+
+	results := search(t, s, collection, []float32{1, 0}, 10, nil)
+	if want := []string{"exact", "near", "far"}; !reflect.DeepEqual(ids(results), want) {
+		t.Fatalf("order = %v, want %v", ids(results), want)
+	}
+	if math.Abs(results[2].Score-9) > 1e-4 {
+		t.Errorf("euclid score = %f, want distance 9", results[2].Score)
+	}
+}
+
+func testTopKBounds(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 2, domain.DistanceCosine)
+	for i := 0; i < 5; i++ {
+		index(t, s, collection, fmt.Sprintf("p%d", i), []float32{1, float32(i)}, nil)
+	}
+	if got := search(t, s, collection, []float32{1, 0}, 2, nil); len(got) != 2 {
+		t.Errorf("topK=2 returned %d results", len(got))
+	}
+	if got := search(t, s, collection, []float32{1, 0}, 100, nil); len(got) != 5 {
+		t.Errorf("topK=100 returned %d results, want all 5", len(got))
+	}
+	if _, err := s.Search(context.Background(), collection, []float32{1, 0}, 0); err == nil {
+		t.Error("topK=0 should fail")
+	}
+}
+
+func testFilters(t *testing.T, s ports.VectorStoreService, collection string) {
+	ensure(t, s, collection, 2, domain.DistanceCosine)
+	index(t, s, collection, "a", []float32{1, 0}, map[string]interface{}{"documentId": "doc1", "clusterIds": []int{1, 2}, "draft": true})
+	index(t, s, collection, "b", []float32{1, 0.1}, map[string]interface{}{"documentId": "doc1", "clusterIds": []int{3}, "draft": false})
+	index(t, s, collection, "c", []float32{1, 0.2}, map[string]interface{}{"documentId": "doc2", "clusterIds": []int{2}, "draft": false})
+
+	tests := []struct {
+		filter domain.PayloadFilter
+		want   []string
+	}{
+		{domain.PayloadFilter{"documentId": "doc1"}, []string{"a", "b"}},
+		{domain.PayloadFilter{"clusterIds": 2}, []string{"a", "c"}},
+		{domain.PayloadFilter{"draft": false, "documentId": "doc2"}, []string{"c"}},
+		{domain.PayloadFilter{"documentId": "missing"}, []string{}},
+		{domain.PayloadFilter{"absentField": "x"}, []string{}},
+	}
+	for _, tt := range tests {
+		if got := ids(search(t, s, collection, []float32{1, 0}, 10, tt.filter)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %v = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	_, err := s.SearchWithFilter(context.Background(), collection, []float32{1, 0}, 10, domain.PayloadFilter{"score": 0.5})
+	if !errors.Is(err, domain.ErrUnsupportedFilter) {
+		t.Errorf("float filter: expected ErrUnsupportedFilter, got %v", err)
+	}
+}
+
+func testDelete(t *testing.T, s ports.VectorStoreService, collection string) {
+	ctx := context.Background()
+	ensure(t, s, collection, 2, domain.DistanceCosine)
+	index(t, s, collection, "keep", []float32{1, 0}, nil)
+	index(t, s, collection, "drop", []float32{0, 1}, nil)
+
+	if err := s.Delete(ctx, collection, []string{"drop", "never-indexed"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Get(ctx, collection, "drop"); !errors.Is(err, domain.ErrPointNotFound) {
+		t.Errorf("Get after delete: expected ErrPointNotFound, got %v", err)
+	}
+	if got := ids(search(t, s, collection, []float32{0, 1}, 10, nil)); !reflect.DeepEqual(got, []string{"keep"}) {
+		t.Errorf("search after delete = %v", got)
+	}
+}
+
+func testDimensionMismatch(t *testing.T, s ports.VectorStoreService, collection string) {
+	ctx := context.Background()
+	ensure(t, s, collection, 3, domain.DistanceCosine)
+	index(t, s, collection, "ok", []float32{1, 0, 0}, nil)
+
+	if err := s.Index(ctx, collection, "bad", []float32{1, 0}, nil); !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("Index: expected ErrInvalidVectorSize, got %v", err)
+	}
+	if _, err := s.Search(ctx, collection, []float32{1, 0, 0, 0}, 1); !errors.Is(err, domain.ErrInvalidVectorSize) {
+		t.Errorf("Search: expected ErrInvalidVectorSize, got %v", err)
+	}
+}
+
+func testMissingCollection(t *testing.T, s ports.VectorStoreService, collection string) {
+	ctx := context.Background()
+	if err := s.Index(ctx, collection, "a", []float32{1}, nil); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("Index: expected ErrCollectionNotFound, got %v", err)
+	}
+	if _, err := s.Search(ctx, collection, []float32{1}, 1); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("Search: expected ErrCollectionNotFound, got %v", err)
+	}
+	if _, err := s.Get(ctx, collection, "a"); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("Get: expected ErrCollectionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// MemoryDocumentRepository is an in-process DocumentRepository, suitable for the demo and for tests
+type MemoryDocumentRepository struct {
+	mu   sync.RWMutex
+	docs map[string]domain.Document
+}
+
+// NewMemoryDocumentRepository creates an empty in-memory document repository
+func NewMemoryDocumentRepository() *MemoryDocumentRepository {
+	return &MemoryDocumentRepository{docs: make(map[string]domain.Document)}
+}
+
+// Save inserts or replaces a document
+func (r *MemoryDocumentRepository) Save(ctx context.Context, doc domain.Document) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.docs[doc.ID] = doc
+	return nil
+}
+
+// Get returns the document with the given ID
+func (r *MemoryDocumentRepository) Get(ctx context.Context, id string) (domain.Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	doc, ok := r.docs[id]
+	if !ok {
+		return domain.Document{}, domain.ErrDocumentNotFound
+	}
+	return doc, nil
+}
+
+// Delete removes the document with the given ID
+func (r *MemoryDocumentRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.docs, id)
+	return nil
+}
+
+// List returns all documents ordered by creation time
+func (r *MemoryDocumentRepository) List(ctx context.Context) ([]domain.Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	docs := make([]domain.Document, 0, len(r.docs))
+	for _, doc := range r.docs {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].CreatedAt.Equal(docs[j].CreatedAt) {
+			return docs[i].ID < docs[j].ID
+		}
+		return docs[i].CreatedAt.Before(docs[j].CreatedAt)
+	})
+	return docs, nil
+}
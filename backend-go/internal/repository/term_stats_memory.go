@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+// MemoryTermStatsRepository is an in-process TermStatsRepository, suitable for the demo and for tests
+type MemoryTermStatsRepository struct {
+	mu     sync.RWMutex
+	spaces map[string]*spaceTerms
+}
+
+// spaceTerms holds the phrases of each document of a space and how many documents contain each phrase
+type spaceTerms struct {
+	docs map[string][]string
+	df   map[string]int
+}
+
+// NewMemoryTermStatsRepository creates an empty in-memory term statistics repository
+func NewMemoryTermStatsRepository() *MemoryTermStatsRepository {
+	return &MemoryTermStatsRepository{spaces: make(map[string]*spaceTerms)}
+}
+
+// Stats returns the term statistics of space without document excludeID
+func (r *MemoryTermStatsRepository) Stats(ctx context.Context, space, excludeID string) (domain.TermStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.spaces[space]
+	if !ok {
+		return domain.TermStats{DocumentFrequency: map[string]int{}}, nil
+	}
+	stats := domain.TermStats{Documents: len(st.docs), DocumentFrequency: make(map[string]int, len(st.df))}
+	for term, n := range st.df {
+		stats.DocumentFrequency[term] = n
+	}
+	if terms, ok := st.docs[excludeID]; ok {
+		stats.Documents--
+		for _, term := range terms {
+			stats.DocumentFrequency[term]--
+		}
+	}
+	return stats, nil
+}
+
+// SaveTerms replaces the recorded phrases of a document; duplicates in terms count once
+func (r *MemoryTermStatsRepository) SaveTerms(ctx context.Context, space, documentID string, terms []string) error {
+	if documentID == "" {
+		return domain.ErrEmptyDocumentID
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.spaces[space]
+	if !ok {
+		st = &spaceTerms{docs: make(map[string][]string), df: make(map[string]int)}
+		r.spaces[space] = st
+	}
+	for _, term := range st.docs[documentID] {
+		if st.df[term]--; st.df[term] == 0 {
+			delete(st.df, term)
+		}
+	}
+	seen := make(map[string]bool, len(terms))
+	unique := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+			st.df[term]++
+		}
+	}
+	st.docs[documentID] = unique
+	return nil
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// CompletionRequest is the body of a streamed completion request
+type CompletionRequest struct {
+	Prompt string `json:"prompt" binding:"required"`
+}
+
+// AnswerRequest is the body of a streamed answer request: a question and the passages to answer from
+type AnswerRequest struct {
+	Question string   `json:"question" binding:"required"`
+	Context  []string `json:"context"`
+}
+
+// DeltaEvent is the data of a "delta" event, carrying the next piece of generated text
+type DeltaEvent struct {
+	Text string `json:"text"`
+}
+
+// ChatHandler streams LLM generations to clients as Server-Sent Events. A stream is a series of
+// "delta" events followed by a "done" event, or by an "error" event when generation fails midway.
+// Closing the connection cancels the generation.
+type ChatHandler struct {
+	llm ports.StreamingLLM
+}
+
+// NewChatHandler creates a new chat handler
+func NewChatHandler(llm ports.StreamingLLM) *ChatHandler {
+	return &ChatHandler{llm: llm}
+}
+
+// StreamCompletion handles streamed completions of a prompt
+func (h *ChatHandler) StreamCompletion(c *gin.Context) {
+	var req CompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid completion request: %w", err))
+		return
+	}
+	h.stream(c, func(ctx context.Context) (<-chan domain.StreamChunk, error) {
+		return h.llm.StreamCompletion(ctx, req.Prompt)
+	})
+}
+
+// StreamAnswer handles streamed answers to a question over the given context passages
+func (h *ChatHandler) StreamAnswer(c *gin.Context) {
+	var req AnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid answer request: %w", err))
+		return
+	}
+	h.stream(c, func(ctx context.Context) (<-chan domain.StreamChunk, error) {
+		return h.llm.StreamAnswer(ctx, req.Context, req.Question)
+	})
+}
+
+// stream opens a generation bound to the request context and relays it as events. A generation
+// that cannot start is reported with a regular JSON error response.
+func (h *ChatHandler) stream(c *gin.Context, open func(ctx context.Context) (<-chan domain.StreamChunk, error)) {
+	chunks, err := open(c.Request.Context())
+	if err != nil {
+		abortWithError(c, llmErrorStatus(err), err)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	// Producers close the channel when the request context ends, so a client that disconnects
+	// also ends this loop
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			c.SSEvent("error", ErrorResponse{Error: chunk.Err.Error()})
+			c.Writer.Flush()
+			return
+		}
+		c.SSEvent("delta", DeltaEvent{Text: chunk.Delta})
+		c.Writer.Flush()
+	}
+	c.SSEvent("done", gin.H{})
+	c.Writer.Flush()
+}
+
+// llmErrorStatus maps LLM failures to HTTP status codes: rate limiting is passed on, other
+// provider failures are reported as a bad gateway
+func llmErrorStatus(err error) int {
+	var statusErr domain.HTTPStatusError
+	switch {
+	case errors.As(err, &statusErr) && statusErr.HTTPStatus() == http.StatusTooManyRequests:
+		return http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/llm/fake"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func postJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func parseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			events = append(events, current)
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event:"):
+			current.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			current.data = strings.TrimPrefix(line, "data:")
+		}
+	}
+	return events
+}
+
+// streamStub streams its deltas and then fails with err, or fails to open with openErr
+type streamStub struct {
+	*fake.LLM
+	deltas  []string
+	err     error
+	openErr error
+}
+
+func (s *streamStub) StreamCompletion(ctx context.Context, prompt string) (<-chan domain.StreamChunk, error) {
+	if s.openErr != nil {
+		return nil, s.openErr
+	}
+	out := make(chan domain.StreamChunk, len(s.deltas)+1)
+	for _, d := range s.deltas {
+		out <- domain.StreamChunk{Delta: d}
+	}
+	if s.err != nil {
+		out <- domain.StreamChunk{Err: s.err}
+	}
+	close(out)
+	return out, nil
+}
+
+type rateLimited struct{}
+
+func (rateLimited) Error() string   { return "quota exceeded" }
+func (rateLimited) HTTPStatus() int { return http.StatusTooManyRequests }
+
+func TestStreamAnswer(t *testing.T) {
+	router, _ := newTestRouter(t, UploadLimits{})
+	rec := postJSON(router, "/api/v1/answers/stream",
+		`{"question":"What is Qdrant?","context":["Qdrant is a vector database."]}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	events := parseEvents(t, rec.Body.String())
+	if len(events) < 2 || events[len(events)-1].name != "done" {
+		t.Fatalf("stream should end with a done event: %+v", events)
+	}
+	var answer strings.Builder
+	for _, e := range events[:len(events)-1] {
+		var delta DeltaEvent
+		if e.name != "delta" || json.Unmarshal([]byte(e.data), &delta) != nil {
+			t.Fatalf("unexpected event %+v", e)
+		}
+		answer.WriteString(delta.Text)
+	}
+	if answer.String() != "According to passage [1]: Qdrant is a vector database." {
+		t.Errorf("streamed answer = %q", answer.String())
+	}
+
+	if rec := postJSON(router, "/api/v1/answers/stream", `{"context":["x"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("a request without a question got status %d", rec.Code)
+	}
+}
+
+func TestStreamCompletionErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &streamStub{LLM: fake.NewLLM(), openErr: rateLimited{}}
+	router := SetupRouter(nil, NewChatHandler(stub))
+
+	if rec := postJSON(router, "/api/v1/completions/stream", `{"prompt":"hi"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("rate-limited provider got status %d, want 429", rec.Code)
+	}
+
+	stub.openErr = nil
+	stub.deltas = []string{"Hel", "lo"}
+	stub.err = errors.New("connection reset")
+	rec := postJSON(router, "/api/v1/completions/stream", `{"prompt":"hi"}`)
+	events := parseEvents(t, rec.Body.String())
+	if len(events) != 3 || events[2].name != "error" || !strings.Contains(events[2].data, "connection reset") {
+		t.Errorf("a failure midway should end the stream with an error event: %+v", events)
+	}
+}
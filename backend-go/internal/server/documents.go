@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// uploadFormField is the multipart field carrying uploaded files
+const uploadFormField = "files"
+
+// chunkingFormField is the optional multipart field choosing the chunking strategy of the upload
+const chunkingFormField = "chunking"
+
+// UploadLimits bounds the size of upload requests
+type UploadLimits struct {
+	MaxFileSize    int64
+	MaxRequestSize int64
+}
+
+// UploadResponse represents the response for a successful upload
+type UploadResponse struct {
+	Documents []domain.Document `json:"documents"`
+}
+
+// ErrorResponse represents an error returned to API clients
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// DocumentHandler serves document endpoints
+type DocumentHandler struct {
+	uploader  ports.DocumentUploader
+	processor ports.DocumentProcessor
+	limits    UploadLimits
+
+	// ctx bounds background processing; Close cancels it and waits for wg
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDocumentHandler creates a new document handler. Uploaded documents are handed to processor
+// in the background until Close; with a nil processor they stay uploaded.
+func NewDocumentHandler(uploader ports.DocumentUploader, processor ports.DocumentProcessor, limits UploadLimits) *DocumentHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DocumentHandler{uploader: uploader, processor: processor, limits: limits, ctx: ctx, cancel: cancel}
+}
+
+// Close cancels background processing and waits for it to stop
+func (h *DocumentHandler) Close() {
+	h.cancel()
+	h.wg.Wait()
+}
+
+// Upload handles multipart uploads of one or more .txt/.md files. An optional "chunking" field
+// selects the chunking strategy of the uploaded documents.
+func (h *DocumentHandler) Upload(c *gin.Context) {
+	if h.limits.MaxRequestSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.limits.MaxRequestSize)
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			abortWithError(c, http.StatusRequestEntityTooLarge, fmt.Errorf("request exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid multipart form: %w", err))
+		return
+	}
+	defer form.RemoveAll()
+
+	files := form.File[uploadFormField]
+	if len(files) == 0 {
+		abortWithError(c, http.StatusBadRequest, domain.ErrNoFiles)
+		return
+	}
+	var opts domain.IngestOptions
+	if values := form.Value[chunkingFormField]; len(values) > 0 {
+		opts.Chunking = domain.ChunkingStrategy(values[0])
+	}
+	if err := opts.Validate(); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Files are staged under their original names so the uploader can derive each Filename.
+	stagingDir, err := os.MkdirTemp("", "upload-*")
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer os.RemoveAll(stagingDir)
+
+	paths := make([]string, 0, len(files))
+	for i, fh := range files {
+		name := filepath.Base(fh.Filename)
+		if fh.Filename == "" || name == "." || name == "/" {
+			abortWithError(c, http.StatusBadRequest, domain.ErrEmptyFilename)
+			return
+		}
+		if h.limits.MaxFileSize > 0 && fh.Size > h.limits.MaxFileSize {
+			abortWithError(c, http.StatusRequestEntityTooLarge, fmt.Errorf("%w: %s", domain.ErrFileTooLarge, name))
+			return
+		}
+		dir := filepath.Join(stagingDir, strconv.Itoa(i))
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		path := filepath.Join(dir, name)
+		if err := c.SaveUploadedFile(fh, path); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		paths = append(paths, path)
+	}
+
+	docs, err := h.uploader.Upload(c.Request.Context(), paths)
+	if err != nil {
+		abortWithError(c, uploadErrorStatus(err), err)
+		return
+	}
+	if h.processor != nil {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.process(docs, opts)
+		}()
+	}
+	c.JSON(http.StatusCreated, UploadResponse{Documents: docs})
+}
+
+// process ingests uploaded documents one after the other. It outlives the upload request, so
+// it runs under the handler's context and stops once the handler is closed.
+func (h *DocumentHandler) process(docs []domain.Document, opts domain.IngestOptions) {
+	for _, doc := range docs {
+		if h.ctx.Err() != nil {
+			return
+		}
+		if err := h.processor.Process(h.ctx, doc, opts); err != nil {
+			log.Printf("Failed to process document %s: %v", doc.ID, err)
+		}
+	}
+}
+
+// uploadErrorStatus maps domain upload errors to HTTP status codes
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrEmptyFilename),
+		errors.Is(err, domain.ErrNoFiles),
+		errors.Is(err, domain.ErrEmptyText):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnsupportedFileType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func abortWithError(c *gin.Context, status int, err error) {
+	c.AbortWithStatusJSON(status, ErrorResponse{Error: err.Error()})
+}
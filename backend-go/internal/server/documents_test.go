@@ -0,0 +1,276 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/llm/fake"
+	"github.com/ran/demo/backend-go/internal/repository"
+	"github.com/ran/demo/backend-go/internal/service"
+)
+
+type uploadFile struct {
+	name    string
+	content []byte
+}
+
+func newUploadRequest(t *testing.T, files ...uploadFile) *http.Request {
+	t.Helper()
+	return newUploadRequestWithFields(t, nil, files...)
+}
+
+// newUploadRequestWithFields builds an upload request that also carries the given form fields
+func newUploadRequestWithFields(t *testing.T, fields map[string]string, files ...uploadFile) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatalf("failed to write form field: %v", err)
+		}
+	}
+	for _, f := range files {
+		part, err := w.CreateFormFile(uploadFormField, f.name)
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		part.Write(f.content)
+	}
+	w.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/documents", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func newTestRouter(t *testing.T, limits UploadLimits) (*gin.Engine, *repository.MemoryDocumentRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewMemoryDocumentRepository()
+	uploader, err := service.NewFileUploader(t.TempDir(), limits.MaxFileSize, repo)
+	if err != nil {
+		t.Fatalf("failed to create uploader: %v", err)
+	}
+	return SetupRouter(NewDocumentHandler(uploader, nil, limits), NewChatHandler(fake.NewLLM())), repo
+}
+
+func TestUploadDocuments(t *testing.T) {
+	router, repo := newTestRouter(t, UploadLimits{MaxFileSize: 1 << 10, MaxRequestSize: 1 << 16})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newUploadRequest(t,
+		uploadFile{"notes.txt", []byte("Hello world.")},
+		uploadFile{"readme.md", []byte("# 見出し\n本文です。")},
+	))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(resp.Documents) != 2 {
+		t.Fatalf("got %d documents, want 2", len(resp.Documents))
+	}
+	for _, doc := range resp.Documents {
+		if doc.Status != domain.StatusUploaded {
+			t.Errorf("document %s status = %s", doc.ID, doc.Status)
+		}
+		if _, err := repo.Get(context.Background(), doc.ID); err != nil {
+			t.Errorf("document %s not persisted: %v", doc.ID, err)
+		}
+	}
+	if resp.Documents[1].Filename != "readme.md" {
+		t.Errorf("filename = %q", resp.Documents[1].Filename)
+	}
+}
+
+// processCall is a document handed to a processor and its ingestion options
+type processCall struct {
+	doc  domain.Document
+	opts domain.IngestOptions
+}
+
+// recordingProcessor reports each document it is asked to process
+type recordingProcessor chan processCall
+
+func (p recordingProcessor) Process(ctx context.Context, doc domain.Document, opts domain.IngestOptions) error {
+	p <- processCall{doc, opts}
+	return nil
+}
+
+func TestUploadDocumentsStartsProcessing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uploader, err := service.NewFileUploader(t.TempDir(), 0, repository.NewMemoryDocumentRepository())
+	if err != nil {
+		t.Fatalf("failed to create uploader: %v", err)
+	}
+	processed := make(recordingProcessor, 2)
+	router := SetupRouter(NewDocumentHandler(uploader, processed, UploadLimits{}), NewChatHandler(fake.NewLLM()))
+	rec := httptest.NewRecorder()
+	fields := map[string]string{chunkingFormField: string(domain.ChunkingSemantic)}
+	router.ServeHTTP(rec, newUploadRequestWithFields(t, fields, uploadFile{"a.txt", []byte("First.")}, uploadFile{"b.txt", []byte("Second.")}))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	for _, want := range resp.Documents {
+		select {
+		case got := <-processed:
+			if got.doc.ID != want.ID {
+				t.Errorf("processed %s, want %s", got.doc.ID, want.ID)
+			}
+			if got.opts.Chunking != domain.ChunkingSemantic {
+				t.Errorf("processed %s with chunking %q, want %q", got.doc.ID, got.opts.Chunking, domain.ChunkingSemantic)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("document %s was not processed", want.ID)
+		}
+	}
+}
+
+// blockingProcessor reports each document it starts and blocks until its context is cancelled
+type blockingProcessor struct {
+	started chan string
+	stopped chan string
+}
+
+func (p blockingProcessor) Process(ctx context.Context, doc domain.Document, opts domain.IngestOptions) error {
+	p.started <- doc.ID
+	<-ctx.Done()
+	p.stopped <- doc.ID
+	return ctx.Err()
+}
+
+func TestDocumentHandlerCloseStopsProcessing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uploader, err := service.NewFileUploader(t.TempDir(), 0, repository.NewMemoryDocumentRepository())
+	if err != nil {
+		t.Fatalf("failed to create uploader: %v", err)
+	}
+	processor := blockingProcessor{started: make(chan string, 2), stopped: make(chan string, 2)}
+	handler := NewDocumentHandler(uploader, processor, UploadLimits{})
+	router := SetupRouter(handler, NewChatHandler(fake.NewLLM()))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newUploadRequest(t, uploadFile{"a.txt", []byte("First.")}, uploadFile{"b.txt", []byte("Second.")}))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case <-processor.started:
+	case <-time.After(time.Second):
+		t.Fatal("processing did not start")
+	}
+
+	handler.Close()
+	select {
+	case <-processor.stopped:
+	default:
+		t.Fatal("Close returned before processing stopped")
+	}
+	if len(processor.started) != 0 {
+		t.Error("processing continued with the next document after Close")
+	}
+}
+
+func TestUploadRejectsUnknownChunkingStrategy(t *testing.T) {
+	router, repo := newTestRouter(t, UploadLimits{})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newUploadRequestWithFields(t, map[string]string{chunkingFormField: "paragraphs"}, uploadFile{"a.txt", []byte("First.")}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if docs, _ := repo.List(context.Background()); len(docs) != 0 {
+		t.Errorf("documents stored for a rejected upload: %v", docs)
+	}
+}
+
+func TestUploadMarkdownSniffedAsHTML(t *testing.T) {
+	router, _ := newTestRouter(t, UploadLimits{})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newUploadRequest(t, uploadFile{"notes.md", []byte("<!-- toc -->\n# Notes\n\n<details>More</details>\n")}))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+// failingRepository saves the first saves documents and rejects the ones after them
+type failingRepository struct {
+	*repository.MemoryDocumentRepository
+	saves int
+}
+
+func (r *failingRepository) Save(ctx context.Context, doc domain.Document) error {
+	if r.saves == 0 {
+		return errors.New("database unavailable")
+	}
+	r.saves--
+	return r.MemoryDocumentRepository.Save(ctx, doc)
+}
+
+func TestUploadFailureRemovesStoredDocuments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	repo := &failingRepository{MemoryDocumentRepository: repository.NewMemoryDocumentRepository(), saves: 1}
+	uploader, err := service.NewFileUploader(dir, 0, repo)
+	if err != nil {
+		t.Fatalf("failed to create uploader: %v", err)
+	}
+	router := SetupRouter(NewDocumentHandler(uploader, nil, UploadLimits{}), NewChatHandler(fake.NewLLM()))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newUploadRequest(t, uploadFile{"a.txt", []byte("First.")}, uploadFile{"b.txt", []byte("Second.")}))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("stored files left behind: %v", entries)
+	}
+	if docs, _ := repo.List(context.Background()); len(docs) != 0 {
+		t.Errorf("documents left behind: %v", docs)
+	}
+}
+
+func TestUploadDocumentsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits UploadLimits
+		files  []uploadFile
+		want   int
+	}{
+		{"no files", UploadLimits{}, nil, http.StatusBadRequest},
+		{"empty file", UploadLimits{}, []uploadFile{{"a.txt", nil}}, http.StatusBadRequest},
+		{"bad extension", UploadLimits{}, []uploadFile{{"a.pdf", []byte("text")}}, http.StatusUnsupportedMediaType},
+		{"binary content", UploadLimits{}, []uploadFile{{"a.txt", []byte{0x89, 'P', 'N', 'G', 0, 0, 0, 0}}}, http.StatusUnsupportedMediaType},
+		{"NUL byte", UploadLimits{}, []uploadFile{{"a.txt", []byte("text\x00more")}}, http.StatusUnsupportedMediaType},
+		{"sniffed as PDF", UploadLimits{}, []uploadFile{{"a.txt", []byte("%PDF-1.7 plain looking text")}}, http.StatusUnsupportedMediaType},
+		{"invalid UTF-8 after the sniffed bytes", UploadLimits{}, []uploadFile{{"a.txt", append(bytes.Repeat([]byte("a"), 40000), 0xff)}}, http.StatusUnsupportedMediaType},
+		{"file too large", UploadLimits{MaxFileSize: 4}, []uploadFile{{"a.txt", []byte("too long")}}, http.StatusRequestEntityTooLarge},
+		{"request too large", UploadLimits{MaxRequestSize: 64}, []uploadFile{{"a.txt", bytes.Repeat([]byte("a"), 256)}}, http.StatusRequestEntityTooLarge},
+		{"one bad file rejects all", UploadLimits{}, []uploadFile{{"a.txt", []byte("ok")}, {"b.exe", []byte("x")}}, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newTestRouter(t, tt.limits)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newUploadRequest(t, tt.files...))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d, body = %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if docs, _ := repo.List(context.Background()); len(docs) != 0 {
+				t.Errorf("expected no persisted documents, got %d", len(docs))
+			}
+		})
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // SetupRouter creates and configures a new HTTP router
-func SetupRouter() *gin.Engine {
+func SetupRouter(documents *DocumentHandler, chat *ChatHandler) *gin.Engine {
 	router := gin.Default()
 
 	// Register routes
@@ -16,7 +16,10 @@ func SetupRouter() *gin.Engine {
 	// API v1 group
 	v1 := router.Group("/api/v1")
 	{
-		// Future endpoints will be added here
+		v1.POST("/documents", documents.Upload)
+		v1.POST("/completions/stream", chat.StreamCompletion)
+		v1.POST("/answers/stream", chat.StreamAnswer)
+
 		v1.GET("/", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
 				"message": "Welcome to TextViz API v1",
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// FallbackAnalyzer routes vector analysis to a primary service (the Python ML service)
+// and falls back to a local one when the primary fails. Inputs with fewer than
+// SmallSpaceThreshold vectors go straight to the local service.
+type FallbackAnalyzer struct {
+	primary             ports.VectorAnalysisService
+	fallback            ports.VectorAnalysisService
+	smallSpaceThreshold int
+}
+
+// NewFallbackAnalyzer creates a new fallback analyzer. primary may be nil, in which case
+// every call is served by fallback.
+func NewFallbackAnalyzer(primary, fallback ports.VectorAnalysisService, smallSpaceThreshold int) *FallbackAnalyzer {
+	return &FallbackAnalyzer{
+		primary:             primary,
+		fallback:            fallback,
+		smallSpaceThreshold: smallSpaceThreshold,
+	}
+}
+
+// Reduce projects vectors with the primary service, or with the fallback when the primary fails
+func (a *FallbackAnalyzer) Reduce(ctx context.Context, vectors [][]float32) ([][]float32, error) {
+	if a.useFallback(len(vectors)) {
+		return a.fallback.Reduce(ctx, vectors)
+	}
+	coords, err := a.primary.Reduce(ctx, vectors)
+	if err == nil || ctx.Err() != nil {
+		return coords, err
+	}
+	coords, fbErr := a.fallback.Reduce(ctx, vectors)
+	if fbErr != nil {
+		return nil, domain.NewErrVectorAnalysis(fmt.Errorf("primary: %w; fallback: %w", err, fbErr))
+	}
+	return coords, nil
+}
+
+// Cluster groups vectors with the primary service, or with the fallback when the primary fails
+func (a *FallbackAnalyzer) Cluster(ctx context.Context, vectors [][]float32, opts domain.ClusterOptions) ([]domain.Cluster, error) {
+	if a.useFallback(len(vectors)) {
+		return a.fallback.Cluster(ctx, vectors, opts)
+	}
+	clusters, err := a.primary.Cluster(ctx, vectors, opts)
+	if err == nil || ctx.Err() != nil {
+		return clusters, err
+	}
+	clusters, fbErr := a.fallback.Cluster(ctx, vectors, opts)
+	if fbErr != nil {
+		return nil, domain.NewErrVectorAnalysis(fmt.Errorf("primary: %w; fallback: %w", err, fbErr))
+	}
+	return clusters, nil
+}
+
+func (a *FallbackAnalyzer) useFallback(n int) bool {
+	return a.primary == nil || n < a.smallSpaceThreshold
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+)
+
+type failingAnalyzer struct{ calls int }
+
+func (f *failingAnalyzer) Reduce(ctx context.Context, vectors [][]float32) ([][]float32, error) {
+	f.calls++
+	return nil, domain.NewErrVectorAnalysis(errors.New("connection refused"))
+}
+
+func (f *failingAnalyzer) Cluster(ctx context.Context, vectors [][]float32, opts domain.ClusterOptions) ([]domain.Cluster, error) {
+	f.calls++
+	return nil, domain.NewErrVectorAnalysis(errors.New("connection refused"))
+}
+
+func TestFallbackAnalyzer(t *testing.T) {
+	primary := &failingAnalyzer{}
+	analyzer := NewFallbackAnalyzer(primary, fakeAnalyzer{}, 3)
+	vectors := [][]float32{{1, 2, 3}, {4, 5, 6}}
+
+	// Below the threshold the primary is never asked
+	if _, err := analyzer.Reduce(context.Background(), vectors); err != nil || primary.calls != 0 {
+		t.Fatalf("small input: err=%v primary calls=%d", err, primary.calls)
+	}
+
+	vectors = append(vectors, []float32{7, 8, 9})
+	coords, err := analyzer.Reduce(context.Background(), vectors)
+	if err != nil || len(coords) != 3 || primary.calls != 1 {
+		t.Fatalf("expected fallback after primary failure: coords=%v err=%v calls=%d", coords, err, primary.calls)
+	}
+	clusters, err := analyzer.Cluster(context.Background(), vectors, domain.ClusterOptions{})
+	if err != nil || len(clusters) != 1 || clusters[0].Label != 7 {
+		t.Fatalf("expected fallback clusters, got %+v (%v)", clusters, err)
+	}
+
+	both := NewFallbackAnalyzer(primary, &failingAnalyzer{}, 0)
+	if _, err := both.Cluster(context.Background(), vectors, domain.ClusterOptions{}); !errors.Is(err, domain.ErrVectorAnalysis) {
+		t.Errorf("expected ErrVectorAnalysis when both fail, got %v", err)
+	}
+}
@@ -3,25 +3,54 @@ package service
 import (
 	"fmt"
 
-	"github.com/ran/demo/backend-go/internal/domain/models"
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
 )
 
-// BuildChunks wraps each text into a Chunk, assigning IDs, token counts, and keywords.
-func BuildChunks(docID string, texts []string, keywords [][]string) ([]models.Chunk, error) {
-	if len(keywords) != len(texts) {
-		return nil, fmt.Errorf("keywords length %d does not match texts length %d", len(keywords), len(texts))
+// BuildChunks wraps each segment into a Chunk, assigning IDs, token counts (as counted by tok), heading paths, overlap ranges,
+// tree levels, parent IDs, and keywords. Indices follow segment order within each level, so overlapping segments do not shift
+// chunk IDs. Paragraphs are IDed "<doc>_<index>" as in a flat chunking; other levels "<doc>_<level>_<index>".
+func BuildChunks(docID string, segments []Segment, keywords [][]string, tok ports.Tokenizer) ([]domain.Chunk, error) {
+	if len(keywords) != len(segments) {
+		return nil, fmt.Errorf("keywords length %d does not match segments length %d", len(keywords), len(segments))
 	}
-	var chunks []models.Chunk
-	for i, txt := range texts {
-		id := fmt.Sprintf("%s_%d", docID, i)
-		tokenCount := CountTokens(txt)
-		chunk := models.Chunk{
-			ID:         id,
-			DocumentID: docID,
-			Index:      i,
-			Text:       txt,
-			TokenCount: tokenCount,
-			Keywords:   keywords[i],
+	ids := make([]string, len(segments))
+	indices := make([]int, len(segments))
+	counts := make(map[domain.ChunkLevel]int)
+	for i, seg := range segments {
+		level := chunkLevel(seg)
+		indices[i] = counts[level]
+		counts[level]++
+		if level == domain.ChunkLevelParagraph {
+			ids[i] = fmt.Sprintf("%s_%d", docID, indices[i])
+		} else {
+			ids[i] = fmt.Sprintf("%s_%s_%d", docID, level, indices[i])
+		}
+	}
+	var chunks []domain.Chunk
+	for i, seg := range segments {
+		txt := seg.Text
+		id := ids[i]
+		tokenCount := tok.CountTokens(txt)
+		chunk := domain.Chunk{
+			ID:          id,
+			DocumentID:  docID,
+			Index:       indices[i],
+			Text:        txt,
+			TokenCount:  tokenCount,
+			Keywords:    keywords[i],
+			Span:        seg.Span,
+			HeadingPath: seg.HeadingPath,
+			Level:       chunkLevel(seg),
+		}
+		if seg.Level != "" && seg.Parent >= 0 {
+			if seg.Parent >= len(segments) || seg.Parent == i {
+				return nil, fmt.Errorf("invalid parent %d for chunk %s", seg.Parent, id)
+			}
+			chunk.ParentID = ids[seg.Parent]
+		}
+		if seg.Overlap > 0 {
+			chunk.Overlap = &domain.TextRange{Start: 0, End: seg.Overlap}
 		}
 		if err := chunk.Validate(); err != nil {
 			return nil, fmt.Errorf("invalid chunk %s: %w", id, err)
@@ -31,16 +60,29 @@ func BuildChunks(docID string, texts []string, keywords [][]string) ([]models.Ch
 	return chunks, nil
 }
 
+// chunkLevel returns the tree level of seg, defaulting to a paragraph
+func chunkLevel(seg Segment) domain.ChunkLevel {
+	if seg.Level == "" {
+		return domain.ChunkLevelParagraph
+	}
+	return seg.Level
+}
+
 // BuildSummary wraps summaryText into a Summary model for the document.
-func BuildSummary(docID, summaryText string) (models.Summary, error) {
-	id := fmt.Sprintf("%s_summary", docID)
-	summary := models.Summary{
+func BuildSummary(docID, summaryText string) (domain.Summary, error) {
+	id := summaryID(docID)
+	summary := domain.Summary{
 		ID:         id,
 		DocumentID: docID,
 		Text:       summaryText,
 	}
 	if err := summary.Validate(); err != nil {
-		return models.Summary{}, fmt.Errorf("invalid summary %s: %w", id, err)
+		return domain.Summary{}, fmt.Errorf("invalid summary %s: %w", id, err)
 	}
 	return summary, nil
-}
\ No newline at end of file
+}
+
+// summaryID returns the ID of a document's summary
+func summaryID(docID string) string {
+	return fmt.Sprintf("%s_summary", docID)
+}
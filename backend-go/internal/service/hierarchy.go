@@ -0,0 +1,102 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// DefaultMaxTokensPerSection bounds section chunks when IngestionConfig sets no limit
+const DefaultMaxTokensPerSection = 1024
+
+// BuildHierarchy turns the paragraph segments of raw into a chunk tree. The paragraphs come
+// first, in their original order, followed by one section per run of paragraphs sharing a
+// heading path (only when the document has headings), then the sentences of each paragraph
+// that has more than one. Sentences never include a paragraph's overlap prefix, and sections
+// cover their paragraphs without the overlap repeated into the first one. A run longer than
+// maxSectionTokens, as counted by tok, is split into consecutive sections of whole paragraphs
+// that each stay within the limit, unless a paragraph alone exceeds it.
+func BuildHierarchy(raw string, paragraphs []Segment, lang Language, maxSectionTokens int, tok ports.Tokenizer) []Segment {
+	tree := make([]Segment, len(paragraphs))
+	hasHeadings := false
+	for i, p := range paragraphs {
+		p.Level, p.Parent = domain.ChunkLevelParagraph, -1
+		tree[i] = p
+		hasHeadings = hasHeadings || p.HeadingPath != ""
+	}
+	lines := newLineIndex(raw)
+
+	if hasHeadings {
+		for i := 0; i < len(paragraphs); {
+			first := paragraphs[i]
+			sp := ownSpan(raw, first)
+			end := i + 1
+			for end < len(paragraphs) && paragraphs[end].HeadingPath == first.HeadingPath &&
+				tok.CountTokens(raw[sp.start:paragraphs[end].Span.EndByte]) <= maxSectionTokens {
+				end++
+			}
+			sp.end = paragraphs[end-1].Span.EndByte
+			for j := i; j < end; j++ {
+				tree[j].Parent = len(tree)
+			}
+			tree = append(tree, Segment{
+				Text:        raw[sp.start:sp.end],
+				Span:        lines.locate(sp),
+				HeadingPath: first.HeadingPath,
+				Level:       domain.ChunkLevelSection,
+				Parent:      -1,
+			})
+			i = end
+		}
+	}
+
+	for i, p := range paragraphs {
+		own := ownSpan(raw, p)
+		sentences := sentenceSpans(raw[own.start:own.end], lang)
+		if len(sentences) < 2 {
+			continue
+		}
+		for _, s := range sentences {
+			sp := span{own.start + s.start, own.start + s.end}
+			tree = append(tree, Segment{
+				Text:        raw[sp.start:sp.end],
+				Span:        lines.locate(sp),
+				HeadingPath: p.HeadingPath,
+				Level:       domain.ChunkLevelSentence,
+				Parent:      i,
+			})
+		}
+	}
+	return tree
+}
+
+// treeKeywords derives keywords for the sections and sentences of tree from those of its
+// paragraphs, which are given in paragraphKeywords: a section merges the keywords of its
+// paragraphs and a sentence keeps those of its paragraph that it mentions.
+func treeKeywords(tree []Segment, paragraphKeywords [][]string) [][]string {
+	keywords := make([][]string, len(tree))
+	copy(keywords, paragraphKeywords)
+	children := make(map[int][][]string)
+	for i, seg := range tree {
+		if seg.Level == domain.ChunkLevelParagraph && seg.Parent >= 0 {
+			children[seg.Parent] = append(children[seg.Parent], keywords[i])
+		}
+	}
+	for i, seg := range tree {
+		switch seg.Level {
+		case domain.ChunkLevelSection:
+			keywords[i] = mergeKeywords(children[i])
+		case domain.ChunkLevelSentence:
+			text := strings.ToLower(seg.Text)
+			var kws []string
+			for _, kw := range keywords[seg.Parent] {
+				if strings.Contains(text, strings.ToLower(kw)) {
+					kws = append(kws, kw)
+				}
+			}
+			keywords[i] = kws
+		}
+	}
+	return keywords
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+func TestBuildHierarchy(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	raw := "# Cats\n\nCats sleep. Cats purr.\n\nKittens play.\n\n# Dogs\n\nDogs bark."
+	paragraphs, err := SegmentMarkdown(raw, 6, tok)
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	tree := BuildHierarchy(raw, paragraphs, LanguageEnglish, DefaultMaxTokensPerSection, tokenizer.NewCharTokenizer())
+	keywords := treeKeywords(tree, [][]string{{"cats", "sleep"}, {"kittens"}, {"dogs"}})
+	chunks, err := BuildChunks("doc", tree, keywords, tok)
+	if err != nil {
+		t.Fatalf("BuildChunks failed: %v", err)
+	}
+
+	byID := make(map[string]domain.Chunk)
+	for _, c := range chunks {
+		byID[c.ID] = c
+		if raw[c.Span.StartByte:c.Span.EndByte] != c.Text {
+			t.Errorf("chunk %s span %+v does not cover its text", c.ID, c.Span)
+		}
+	}
+	tests := []struct {
+		id, text, parent string
+		level            domain.ChunkLevel
+		keywords         int
+	}{
+		{"doc_0", "# Cats\n\nCats sleep. Cats purr.", "doc_section_0", domain.ChunkLevelParagraph, 2},
+		{"doc_1", "Kittens play.", "doc_section_0", domain.ChunkLevelParagraph, 1},
+		{"doc_2", "# Dogs\n\nDogs bark.", "doc_section_1", domain.ChunkLevelParagraph, 1},
+		{"doc_section_0", "# Cats\n\nCats sleep. Cats purr.\n\nKittens play.", "", domain.ChunkLevelSection, 3},
+		{"doc_section_1", "# Dogs\n\nDogs bark.", "", domain.ChunkLevelSection, 1},
+		{"doc_sentence_0", "# Cats", "doc_0", domain.ChunkLevelSentence, 1},
+		{"doc_sentence_1", "Cats sleep.", "doc_0", domain.ChunkLevelSentence, 2},
+		{"doc_sentence_2", "Cats purr.", "doc_0", domain.ChunkLevelSentence, 1},
+		{"doc_sentence_3", "# Dogs", "doc_2", domain.ChunkLevelSentence, 1},
+		{"doc_sentence_4", "Dogs bark.", "doc_2", domain.ChunkLevelSentence, 1},
+	}
+	if len(chunks) != len(tests) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(tests), chunks)
+	}
+	for _, tt := range tests {
+		c, ok := byID[tt.id]
+		if !ok {
+			t.Errorf("missing chunk %s", tt.id)
+			continue
+		}
+		if c.Text != tt.text || c.ParentID != tt.parent || c.Level != tt.level || len(c.Keywords) != tt.keywords {
+			t.Errorf("chunk %s = %q level %s parent %q keywords %v", tt.id, c.Text, c.Level, c.ParentID, c.Keywords)
+		}
+	}
+}
+
+func TestBuildHierarchySplitsLongSections(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	raw := "# Cats\n\nCats sleep.\n\nCats purr.\n\nKittens play.\n\nKittens nap."
+	paragraphs, err := SegmentMarkdown(raw, 3, tok)
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	const limit = 6
+	var sections []string
+	for _, seg := range BuildHierarchy(raw, paragraphs, LanguageEnglish, limit, tok) {
+		if seg.Level != domain.ChunkLevelSection {
+			continue
+		}
+		sections = append(sections, seg.Text)
+		if n := tok.CountTokens(seg.Text); n > limit {
+			t.Errorf("section %q has %d tokens, limit %d", seg.Text, n, limit)
+		}
+		if seg.HeadingPath != "Cats" {
+			t.Errorf("section %q lost its heading path: %q", seg.Text, seg.HeadingPath)
+		}
+	}
+	if len(sections) < 2 || strings.Join(sections, "\n\n") != raw {
+		t.Errorf("sections should split the document in order, got %q", sections)
+	}
+}
+
+func TestBuildHierarchyWithoutHeadings(t *testing.T) {
+	raw := "One two. Three four. Five six."
+	paragraphs, err := SegmentProse(raw, 4, tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("SegmentProse failed: %v", err)
+	}
+	paragraphs = ApplyOverlap(raw, paragraphs, Overlap{Sentences: 1}, 1000, LanguageEnglish, tokenizer.NewCharTokenizer())
+	tree := BuildHierarchy(raw, paragraphs, LanguageEnglish, DefaultMaxTokensPerSection, tokenizer.NewCharTokenizer())
+	for _, seg := range tree {
+		if seg.Level == domain.ChunkLevelSection {
+			t.Errorf("unexpected section %q in a document without headings", seg.Text)
+		}
+		if seg.Level == domain.ChunkLevelParagraph && seg.Parent != -1 {
+			t.Errorf("paragraph %q should be top-level, got parent %d", seg.Text, seg.Parent)
+		}
+	}
+	// The second paragraph repeats "Three four." from the first; that leaves it only one
+	// sentence of its own, which would duplicate the paragraph
+	if last := tree[len(tree)-1]; len(tree) != 4 || last.Text != "Three four." || last.Parent != 0 {
+		t.Errorf("unexpected tree %+v", tree)
+	}
+}
+
+func TestIngestionServiceProcessHierarchical(t *testing.T) {
+	store := &fakeStore{}
+	cfg := testIngestionConfig
+	cfg.Hierarchical = true
+	svc := newTestIngestion(t, &fakeLLM{}, store, cfg)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+
+	result, err := svc.Process(context.Background(), doc, "One two three. Four five six. Seven eight.", domain.IngestOptions{})
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	levels := make(map[domain.ChunkLevel]int)
+	for _, c := range result.Chunks {
+		levels[c.Level]++
+		if c.Coord2D == nil {
+			t.Errorf("chunk %s was not analysed", c.ID)
+		}
+	}
+	if levels[domain.ChunkLevelParagraph] != 2 || levels[domain.ChunkLevelSentence] != 2 {
+		t.Errorf("unexpected levels %v", levels)
+	}
+	if len(store.indexed) != len(result.Chunks)+1 {
+		t.Fatalf("indexed %d points, want %d", len(store.indexed), len(result.Chunks)+1)
+	}
+	if meta := store.indexed[len(result.Chunks)-1].meta; meta["level"] != "sentence" || meta["parentId"] == "" {
+		t.Errorf("sentence payload lacks tree metadata: %v", meta)
+	}
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// IngestionConfig holds the tunables of the ingestion pipeline
+type IngestionConfig struct {
+	MaxTokensPerChunk   int
+	ChunksCollection    string
+	SummariesCollection string
+	// Chunking selects how plain-text documents are segmented unless an ingestion chooses its
+	// own strategy; empty means ChunkingFixed.
+	// Markdown documents always follow their heading structure.
+	Chunking ChunkingStrategy
+	// Semantic tunes ChunkingSemantic.
+	Semantic SemanticChunking
+	// Overlap repeats the end of each chunk at the start of the next. A token overlap is
+	// carved out of MaxTokensPerChunk, so chunks stay within budget.
+	Overlap Overlap
+	// Hierarchical adds sections and sentences around the paragraph chunks, each embedded
+	// and indexed with a parent link, so clients can zoom between granularities.
+	// Keywords are extracted for paragraphs only and derived for the other levels.
+	Hierarchical bool
+	// MaxTokensPerSection bounds section chunks, splitting longer sections; zero selects
+	// DefaultMaxTokensPerSection.
+	MaxTokensPerSection int
+	// Keywords extracts chunk keywords locally. Required.
+	Keywords ports.KeywordExtractor
+	// TermStats keeps the keyword phrases of the documents of the space, so keywords common
+	// across its documents rank lower; nil weighs terms within each document only. The space
+	// is ChunksCollection.
+	TermStats ports.TermStatsRepository
+	// MaxDocumentKeywords caps Document.Keywords; zero selects DefaultMaxDocumentKeywords.
+	MaxDocumentKeywords int
+	// RefineKeywords additionally asks the LLM for each chunk's keywords, one call per chunk,
+	// and puts them ahead of the local ones.
+	RefineKeywords bool
+	// Summary bounds the text sent to the LLM per summarization call; longer documents are
+	// summarized map-reduce style.
+	Summary SummarizerConfig
+	// Tokenizer counts tokens for chunking and token budgets. Required.
+	Tokenizer ports.Tokenizer
+	// MinClusterProbability lets a chunk join every cluster it belongs to with at least
+	// this probability; zero assigns each chunk to a single cluster.
+	MinClusterProbability float64
+}
+
+// DefaultMaxDocumentKeywords is the number of document keywords kept when none is configured
+const DefaultMaxDocumentKeywords = 20
+
+// IngestionResult holds everything produced while processing a document
+type IngestionResult struct {
+	Chunks  []domain.Chunk
+	Summary domain.Summary
+	// PartialSummaries are the intermediate summaries of a document too long to summarize in one call.
+	PartialSummaries []PartialSummary
+	Clusters         []domain.Cluster
+}
+
+// IngestionService drives a Document through segmentation, enrichment, embedding,
+// analysis and indexing, keeping its ProcessingStatus up to date along the way.
+type IngestionService struct {
+	llm        ports.LLM
+	embedder   ports.EmbeddingModel
+	analyzer   ports.VectorAnalysisService
+	store      ports.VectorStoreService
+	cfg        IngestionConfig
+	summarizer *Summarizer
+	now        func() time.Time
+}
+
+// NewIngestionService creates a new ingestion pipeline over the given ports
+func NewIngestionService(llm ports.LLM, embedder ports.EmbeddingModel, analyzer ports.VectorAnalysisService, store ports.VectorStoreService, cfg IngestionConfig) (*IngestionService, error) {
+	if cfg.Tokenizer == nil || cfg.Keywords == nil {
+		return nil, errors.New("ingestion requires a tokenizer and a keyword extractor")
+	}
+	if err := cfg.Summary.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid summary configuration: %w", err)
+	}
+	if cfg.MaxDocumentKeywords == 0 {
+		cfg.MaxDocumentKeywords = DefaultMaxDocumentKeywords
+	}
+	if cfg.MaxTokensPerSection == 0 {
+		cfg.MaxTokensPerSection = DefaultMaxTokensPerSection
+	}
+	return &IngestionService{
+		llm:        llm,
+		embedder:   embedder,
+		analyzer:   analyzer,
+		store:      store,
+		cfg:        cfg,
+		summarizer: NewSummarizer(llm, cfg.Tokenizer, cfg.Summary),
+		now:        time.Now,
+	}, nil
+}
+
+// Process ingests the raw text of doc. On success the document ends in StatusCompleted
+// with ProcessedAt and SummaryID set; on failure it ends in StatusFailed with Error set.
+// Options left zero in opts fall back to the service configuration.
+func (s *IngestionService) Process(ctx context.Context, doc *domain.Document, raw string, opts domain.IngestOptions) (*IngestionResult, error) {
+	if err := startProcessing(doc, opts); err != nil {
+		return nil, err
+	}
+	return s.ingest(ctx, doc, raw, opts)
+}
+
+// ingest runs the pipeline on a document already in StatusProcessing and moves it to
+// StatusCompleted or StatusFailed
+func (s *IngestionService) ingest(ctx context.Context, doc *domain.Document, raw string, opts domain.IngestOptions) (*IngestionResult, error) {
+	result, err := s.run(ctx, doc, raw, opts)
+	if err != nil {
+		if tErr := failProcessing(doc, err); tErr != nil {
+			return nil, tErr
+		}
+		return nil, err
+	}
+
+	processedAt := s.now()
+	doc.ProcessedAt = &processedAt
+	doc.SummaryID = &result.Summary.ID
+	if err := doc.TransitionTo(domain.StatusCompleted); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// startProcessing validates doc and opts and moves doc to StatusProcessing, clearing any earlier error
+func startProcessing(doc *domain.Document, opts domain.IngestOptions) error {
+	if err := doc.Validate(); err != nil {
+		return fmt.Errorf("invalid document %s: %w", doc.ID, err)
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := doc.TransitionTo(domain.StatusProcessing); err != nil {
+		return err
+	}
+	doc.Error = nil
+	return nil
+}
+
+// failProcessing records cause on doc and moves it to StatusFailed
+func failProcessing(doc *domain.Document, cause error) error {
+	msg := cause.Error()
+	doc.Error = &msg
+	return doc.TransitionTo(domain.StatusFailed)
+}
+
+func (s *IngestionService) run(ctx context.Context, doc *domain.Document, raw string, opts domain.IngestOptions) (*IngestionResult, error) {
+	segments, err := s.segment(ctx, doc, raw, opts.Chunking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to segment document: %w", err)
+	}
+	if len(segments) == 0 {
+		return nil, domain.ErrEmptyText
+	}
+
+	scored, terms, err := s.extractKeywords(ctx, doc.ID, segments)
+	if err != nil {
+		return nil, err
+	}
+	doc.Keywords = rankKeywords(scored, s.cfg.MaxDocumentKeywords)
+	kws := make([][]string, len(scored))
+	for i, chunkKeywords := range scored {
+		kws[i] = domain.KeywordTexts(chunkKeywords)
+	}
+	if s.cfg.Hierarchical {
+		segments = BuildHierarchy(raw, segments, DetectLanguage(raw), s.cfg.MaxTokensPerSection, s.cfg.Tokenizer)
+		kws = treeKeywords(segments, kws)
+	}
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+
+	chunks, err := BuildChunks(doc.ID, segments, kws, s.cfg.Tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	summarized, err := s.summarizer.Summarize(ctx, doc.ID, raw, chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize document: %w", err)
+	}
+	summary := summarized.Summary
+
+	// The summary is embedded and analysed together with its chunks so they share one space.
+	// Partial summaries are embedded in the same call but left out of the analysis.
+	partials := summarized.Partials
+	inputs := append(append([]string{}, texts...), summary.Text)
+	for _, p := range partials {
+		inputs = append(inputs, p.Text)
+	}
+	vectors, err := s.embedder.GenerateEmbeddings(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed document: %w", err)
+	}
+	if len(vectors) != len(inputs) {
+		return nil, fmt.Errorf("embedding count mismatch: expected %d, got %d", len(inputs), len(vectors))
+	}
+	for i := range chunks {
+		chunks[i].Embedding = vectors[i]
+	}
+	summary.Embedding = vectors[len(chunks)]
+	for i := range partials {
+		partials[i].Embedding = vectors[len(chunks)+1+i]
+	}
+	vectors = vectors[:len(chunks)+1]
+
+	reduced, err := s.analyzer.Reduce(ctx, vectors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reduce vectors: %w", err)
+	}
+	if len(reduced) != len(vectors) {
+		return nil, fmt.Errorf("reduced vector count mismatch: expected %d, got %d", len(vectors), len(reduced))
+	}
+	clusters, err := s.analyzer.Cluster(ctx, vectors, domain.ClusterOptions{MinProbability: s.cfg.MinClusterProbability})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cluster vectors: %w", err)
+	}
+	if err := applyAnalysis(chunks, &summary, reduced, clusters); err != nil {
+		return nil, err
+	}
+
+	if err := s.index(ctx, *doc, chunks, summary, partials); err != nil {
+		return nil, err
+	}
+	if s.cfg.TermStats != nil {
+		if err := s.cfg.TermStats.SaveTerms(ctx, s.cfg.ChunksCollection, doc.ID, terms); err != nil {
+			return nil, fmt.Errorf("failed to record keyword statistics: %w", err)
+		}
+	}
+
+	return &IngestionResult{Chunks: chunks, Summary: summary, PartialSummaries: partials, Clusters: clusters}, nil
+}
+
+// extractKeywords returns the scored keywords of each segment and the distinct phrases of the
+// document. Keywords are scored locally against the other segments and the other documents of
+// the space and, when configured, refined by the LLM; refined keywords go first, with the score
+// of the segment's best local keyword.
+func (s *IngestionService) extractKeywords(ctx context.Context, docID string, segments []Segment) ([][]domain.Keyword, []string, error) {
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	terms, err := s.cfg.Keywords.Terms(ctx, texts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract keyword terms: %w", err)
+	}
+	var stats domain.TermStats
+	if s.cfg.TermStats != nil {
+		if stats, err = s.cfg.TermStats.Stats(ctx, s.cfg.ChunksCollection, docID); err != nil {
+			return nil, nil, fmt.Errorf("failed to load keyword statistics: %w", err)
+		}
+	}
+	kws, err := s.cfg.Keywords.ExtractCorpusKeywords(ctx, texts, stats)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract keywords: %w", err)
+	}
+	if len(kws) != len(texts) {
+		return nil, nil, fmt.Errorf("keyword count mismatch: expected %d, got %d", len(texts), len(kws))
+	}
+	if !s.cfg.RefineKeywords {
+		return kws, terms, nil
+	}
+	for i, text := range texts {
+		refined, err := s.llm.ExtractKeywords(ctx, text)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to extract keywords for chunk %d: %w", i, err)
+		}
+		score := 1.0
+		if len(kws[i]) > 0 {
+			score = kws[i][0].Score
+		}
+		merged := make([]domain.Keyword, 0, len(refined)+len(kws[i]))
+		for _, kw := range refined {
+			merged = append(merged, domain.Keyword{Text: kw, Score: score})
+		}
+		kws[i] = dedupKeywords(append(merged, kws[i]...))
+	}
+	return kws, terms, nil
+}
+
+// dedupKeywords drops empty and repeated phrases, keeping the first occurrence
+func dedupKeywords(kws []domain.Keyword) []domain.Keyword {
+	seen := make(map[string]bool, len(kws))
+	out := kws[:0]
+	for _, kw := range kws {
+		if kw.Text == "" || seen[kw.Text] {
+			continue
+		}
+		seen[kw.Text] = true
+		out = append(out, kw)
+	}
+	return out
+}
+
+// rankKeywords returns the document keywords: the phrases of all segments ranked by their total
+// score across segments, ties kept in first-seen order, capped at limit
+func rankKeywords(scored [][]domain.Keyword, limit int) []string {
+	totals := make(map[string]float64)
+	var order []string
+	for _, kws := range scored {
+		for _, kw := range kws {
+			if kw.Text == "" {
+				continue
+			}
+			if _, ok := totals[kw.Text]; !ok {
+				order = append(order, kw.Text)
+			}
+			totals[kw.Text] += kw.Score
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return totals[order[a]] > totals[order[b]] })
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	return order
+}
+
+// segment splits raw into chunk-sized segments, following the markdown structure for markdown
+// documents and the configured strategy otherwise, and overlaps consecutive segments when configured
+func (s *IngestionService) segment(ctx context.Context, doc *domain.Document, raw string, strategy ChunkingStrategy) ([]Segment, error) {
+	if strategy == "" {
+		strategy = s.cfg.Chunking
+	}
+	if err := s.cfg.Overlap.Validate(s.cfg.MaxTokensPerChunk); err != nil {
+		return nil, err
+	}
+	// A token overlap is reserved from the budget; a sentence overlap is trimmed to fit by ApplyOverlap.
+	budget := s.cfg.MaxTokensPerChunk - s.cfg.Overlap.Tokens
+	var (
+		segments []Segment
+		err      error
+	)
+	switch {
+	case IsMarkdown(doc.Filename):
+		segments, err = SegmentMarkdown(raw, budget, s.cfg.Tokenizer)
+	case strategy == ChunkingSemantic:
+		segments, err = SegmentSemantic(ctx, raw, budget, s.cfg.Semantic, s.embedder, s.cfg.Tokenizer)
+	case strategy == "" || strategy == ChunkingFixed:
+		segments, err = SegmentProse(raw, budget, s.cfg.Tokenizer)
+	default:
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownChunkingStrategy, strategy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ApplyOverlap(raw, segments, s.cfg.Overlap, s.cfg.MaxTokensPerChunk, DetectLanguage(raw), s.cfg.Tokenizer), nil
+}
+
+func (s *IngestionService) index(ctx context.Context, doc domain.Document, chunks []domain.Chunk, summary domain.Summary, partials []PartialSummary) error {
+	for _, collection := range []string{s.cfg.ChunksCollection, s.cfg.SummariesCollection} {
+		if err := s.store.EnsureCollection(ctx, collection, s.embedder.GetEmbeddingDimension(), domain.DistanceCosine); err != nil {
+			return fmt.Errorf("failed to prepare collection %s: %w", collection, err)
+		}
+	}
+	if err := s.store.IndexChunks(ctx, s.cfg.ChunksCollection, doc.ID, chunks); err != nil {
+		return fmt.Errorf("failed to index chunks: %w", err)
+	}
+	chunkIDs := make([]string, len(chunks))
+	for i, c := range chunks {
+		chunkIDs[i] = c.ID
+	}
+	if err := s.store.IndexSummaries(ctx, s.cfg.SummariesCollection, doc, summary, chunkIDs, partials); err != nil {
+		return fmt.Errorf("failed to index summaries: %w", err)
+	}
+	return nil
+}
+
+// applyAnalysis copies reduced coordinates and cluster memberships onto the chunks and the summary.
+// Rows of reduced and cluster member IDs are positional: chunks first, then the summary.
+// Chunk memberships are ordered by descending probability; the summary takes its most probable cluster.
+func applyAnalysis(chunks []domain.Chunk, summary *domain.Summary, reduced [][]float32, clusters []domain.Cluster) error {
+	for i := range chunks {
+		chunks[i].Coord2D, chunks[i].Coord3D = toCoords(reduced[i])
+		chunks[i].ClusterIDs = nil
+		chunks[i].ClusterProbabilities = nil
+	}
+	summary.Coord2D, summary.Coord3D = toCoords(reduced[len(chunks)])
+	summary.ClusterID = nil
+
+	summaryProb := -1.0
+	for _, cl := range clusters {
+		for j, member := range cl.MemberIDs {
+			pos, err := strconv.Atoi(member)
+			if err != nil || pos < 0 || pos > len(chunks) {
+				return fmt.Errorf("invalid cluster member %q in cluster %d", member, cl.Label)
+			}
+			prob := cl.Probability(j)
+			if pos == len(chunks) {
+				if prob > summaryProb {
+					label := cl.Label
+					summary.ClusterID = &label
+					summaryProb = prob
+				}
+				continue
+			}
+			chunks[pos].ClusterIDs = append(chunks[pos].ClusterIDs, cl.Label)
+			chunks[pos].ClusterProbabilities = append(chunks[pos].ClusterProbabilities, prob)
+		}
+	}
+	for i := range chunks {
+		sortMemberships(&chunks[i])
+	}
+	return nil
+}
+
+// sortMemberships orders a chunk's cluster memberships by descending probability
+func sortMemberships(c *domain.Chunk) {
+	order := make([]int, len(c.ClusterIDs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return c.ClusterProbabilities[order[i]] > c.ClusterProbabilities[order[j]]
+	})
+	ids := make([]int, len(order))
+	probs := make([]float64, len(order))
+	for i, o := range order {
+		ids[i], probs[i] = c.ClusterIDs[o], c.ClusterProbabilities[o]
+	}
+	c.ClusterIDs, c.ClusterProbabilities = ids, probs
+}
+
+func toCoords(v []float32) (*[2]float32, *[3]float32) {
+	switch {
+	case len(v) >= 3:
+		return &[2]float32{v[0], v[1]}, &[3]float32{v[0], v[1], v[2]}
+	case len(v) == 2:
+		return &[2]float32{v[0], v[1]}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// mergeKeywords flattens per-chunk keywords into a de-duplicated document keyword list, keeping first-seen order.
+func mergeKeywords(keywords [][]string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, kws := range keywords {
+		for _, kw := range kws {
+			if kw == "" || seen[kw] {
+				continue
+			}
+			seen[kw] = true
+			merged = append(merged, kw)
+		}
+	}
+	return merged
+}
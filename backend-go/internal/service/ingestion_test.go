@@ -0,0 +1,549 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+	"github.com/ran/demo/backend-go/internal/infra/keywords"
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/memory"
+	"github.com/ran/demo/backend-go/internal/infra/vectorstore/qdrant"
+	"github.com/ran/demo/backend-go/internal/repository"
+)
+
+type fakeLLM struct {
+	summaryErr error
+}
+
+func (f *fakeLLM) GenerateCompletion(ctx context.Context, prompt string) (string, error) {
+	return prompt, nil
+}
+
+func (f *fakeLLM) GenerateSummary(ctx context.Context, text string) (string, error) {
+	if f.summaryErr != nil {
+		return "", f.summaryErr
+	}
+	return "summary of document", nil
+}
+
+func (f *fakeLLM) AnswerQuestion(ctx context.Context, context []string, question string) (string, error) {
+	return "answer", nil
+}
+
+func (f *fakeLLM) ExtractKeywords(ctx context.Context, text string) ([]string, error) {
+	return []string{"go", "test"}, nil
+}
+
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text)), 1, 0}, nil
+}
+
+func (f fakeEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i], _ = f.GenerateEmbedding(ctx, t)
+	}
+	return out, nil
+}
+
+func (fakeEmbedder) GetEmbeddingDimension() uint64 { return 3 }
+
+type fakeAnalyzer struct{}
+
+func (fakeAnalyzer) Reduce(ctx context.Context, vectors [][]float32) ([][]float32, error) {
+	out := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		out[i] = v[:2]
+	}
+	return out, nil
+}
+
+func (fakeAnalyzer) Cluster(ctx context.Context, vectors [][]float32, opts domain.ClusterOptions) ([]domain.Cluster, error) {
+	cl := domain.Cluster{Label: 7}
+	for i := range vectors {
+		cl.MemberIDs = append(cl.MemberIDs, strconv.Itoa(i))
+	}
+	return []domain.Cluster{cl}, nil
+}
+
+type indexedPoint struct {
+	collection string
+	id         string
+	meta       map[string]interface{}
+}
+
+type fakeStore struct {
+	indexed []indexedPoint
+}
+
+func (f *fakeStore) EnsureCollection(ctx context.Context, collection string, vectorSize uint64, distance domain.Distance) error {
+	return nil
+}
+
+func (f *fakeStore) Index(ctx context.Context, collection string, id string, vector []float32, meta map[string]interface{}) error {
+	f.indexed = append(f.indexed, indexedPoint{collection: collection, id: id, meta: meta})
+	return nil
+}
+
+func (f *fakeStore) Search(ctx context.Context, collection string, vector []float32, topK int) ([]domain.SearchResult, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) SearchWithFilter(ctx context.Context, collection string, vector []float32, topK int, filter domain.PayloadFilter) ([]domain.SearchResult, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) Get(ctx context.Context, collection string, id string) (domain.SearchResult, error) {
+	return domain.SearchResult{}, domain.ErrPointNotFound
+}
+
+func (f *fakeStore) Delete(ctx context.Context, collection string, ids []string) error {
+	return nil
+}
+
+func (f *fakeStore) IndexChunks(ctx context.Context, collection string, documentID string, chunks []domain.Chunk) error {
+	points, err := qdrant.ChunkPoints(chunks)
+	if err != nil {
+		return err
+	}
+	f.record(collection, points)
+	return nil
+}
+
+func (f *fakeStore) IndexSummaries(ctx context.Context, collection string, doc domain.Document, summary domain.Summary, chunkIDs []string, partials []domain.PartialSummary) error {
+	points, err := qdrant.SummaryPoints(doc, summary, chunkIDs, partials)
+	if err != nil {
+		return err
+	}
+	f.record(collection, points)
+	return nil
+}
+
+func (f *fakeStore) record(collection string, points []qdrant.Point) {
+	for _, p := range points {
+		f.indexed = append(f.indexed, indexedPoint{collection: collection, id: p.ID, meta: p.Payload})
+	}
+}
+
+var testIngestionConfig = IngestionConfig{
+	MaxTokensPerChunk:   5,
+	ChunksCollection:    "chunks",
+	SummariesCollection: "summaries",
+	Keywords:            keywords.NewExtractor(keywords.DefaultMaxKeywords),
+	Tokenizer:           tokenizer.NewCharTokenizer(),
+}
+
+// newTestIngestion creates an ingestion service over the fake embedder and analyzer
+func newTestIngestion(t *testing.T, llm ports.LLM, store ports.VectorStoreService, cfg IngestionConfig) *IngestionService {
+	t.Helper()
+	svc, err := NewIngestionService(llm, fakeEmbedder{}, fakeAnalyzer{}, store, cfg)
+	if err != nil {
+		t.Fatalf("NewIngestionService failed: %v", err)
+	}
+	return svc
+}
+
+func TestIngestionServiceProcess(t *testing.T) {
+	store := &fakeStore{}
+	svc := newTestIngestion(t, &fakeLLM{}, store, testIngestionConfig)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+
+	result, err := svc.Process(context.Background(), doc, "One two three. Four five six. Seven eight.", domain.IngestOptions{})
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if doc.Status != domain.StatusCompleted {
+		t.Errorf("status = %s, want %s", doc.Status, domain.StatusCompleted)
+	}
+	if doc.ProcessedAt == nil || doc.SummaryID == nil || *doc.SummaryID != "doc1_summary" {
+		t.Errorf("ProcessedAt/SummaryID not filled: %+v", doc)
+	}
+	if doc.Error != nil {
+		t.Errorf("unexpected error on document: %s", *doc.Error)
+	}
+	if len(result.Chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(result.Chunks))
+	}
+	for _, c := range result.Chunks {
+		if c.Coord2D == nil || len(c.ClusterIDs) != 1 || c.ClusterIDs[0] != 7 {
+			t.Errorf("chunk %s missing analysis data: %+v", c.ID, c)
+		}
+	}
+	if result.Summary.ClusterID == nil || *result.Summary.ClusterID != 7 {
+		t.Errorf("summary cluster not set")
+	}
+	if len(store.indexed) != 3 {
+		t.Fatalf("indexed %d points, want 3", len(store.indexed))
+	}
+	last := store.indexed[2]
+	if last.collection != "summaries" || last.id != "doc1_summary" {
+		t.Errorf("unexpected summary point: %+v", last)
+	}
+}
+
+func TestIngestionServiceKeywords(t *testing.T) {
+	raw := "Vector search ranks chunks. Cluster labels group chunks."
+	for _, refine := range []bool{false, true} {
+		cfg := testIngestionConfig
+		cfg.RefineKeywords = refine
+		svc := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, cfg)
+		doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+		result, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{})
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		kws := result.Chunks[0].Keywords
+		if len(kws) == 0 || (kws[0] == "go") != refine {
+			t.Errorf("refine=%v: unexpected chunk keywords %q", refine, kws)
+		}
+		chunkKeywords := make(map[string]bool)
+		for _, c := range result.Chunks {
+			for _, kw := range c.Keywords {
+				chunkKeywords[kw] = true
+			}
+		}
+		for _, kw := range doc.Keywords {
+			if !chunkKeywords[kw] {
+				t.Errorf("refine=%v: document keyword %q is not a chunk keyword", refine, kw)
+			}
+		}
+	}
+}
+
+func TestIngestionServiceCapsDocumentKeywords(t *testing.T) {
+	cfg := testIngestionConfig
+	cfg.MaxDocumentKeywords = 2
+	svc := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, cfg)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	if _, err := svc.Process(context.Background(), doc, "Vector search ranks chunks. Cluster labels group chunks.", domain.IngestOptions{}); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(doc.Keywords) != 2 {
+		t.Errorf("got %d document keywords %q, want 2", len(doc.Keywords), doc.Keywords)
+	}
+}
+
+func TestIngestionServiceWeighsTermsAcrossSpace(t *testing.T) {
+	cfg := testIngestionConfig
+	cfg.TermStats = repository.NewMemoryTermStatsRepository()
+	svc := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, cfg)
+	const raw = "Qdrant indexes embeddings quickly. Qdrant indexes embeddings again. Payload filters narrow results."
+	rank := func(keywords []string, term string) int {
+		for i, kw := range keywords {
+			if kw == term {
+				return i
+			}
+		}
+		return len(keywords)
+	}
+
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	if _, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{}); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	alone := rank(doc.Keywords, "qdrant indexes embeddings")
+	for _, id := range []string{"doc2", "doc3", "doc4"} {
+		other := &domain.Document{ID: id, Filename: id + ".txt", Status: domain.StatusUploaded}
+		if _, err := svc.Process(context.Background(), other, "Qdrant indexes embeddings.", domain.IngestOptions{}); err != nil {
+			t.Fatalf("Process %s failed: %v", id, err)
+		}
+	}
+	doc = &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	if _, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{}); err != nil {
+		t.Fatalf("re-Process failed: %v", err)
+	}
+	if common := rank(doc.Keywords, "qdrant indexes embeddings"); common <= alone {
+		t.Errorf("common term ranked %d with the space, %d alone; want lower: %q", common, alone, doc.Keywords)
+	}
+}
+
+func TestIngestionServiceIndexesPartialSummaries(t *testing.T) {
+	store := &fakeStore{}
+	cfg := testIngestionConfig
+	cfg.Summary = SummarizerConfig{MaxTokensPerCall: testIngestionConfig.MaxTokensPerChunk}
+	svc := newTestIngestion(t, &summaryLLM{}, store, cfg)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+
+	result, err := svc.Process(context.Background(), doc, "One two three. Four five six. Seven eight.", domain.IngestOptions{})
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(result.PartialSummaries) == 0 {
+		t.Fatal("expected the document to be summarized map-reduce style")
+	}
+	partials := make(map[string]indexedPoint)
+	for _, p := range store.indexed {
+		if p.collection == "summaries" && p.id != "doc1_summary" {
+			partials[p.id] = p
+		}
+	}
+	for _, want := range result.PartialSummaries {
+		got, ok := partials[want.ID]
+		if !ok || len(want.Embedding) == 0 {
+			t.Errorf("partial summary %s was not embedded and indexed", want.ID)
+			continue
+		}
+		if got.meta["level"] != want.Level || got.meta["parentId"] != want.ParentID || got.meta["documentId"] != "doc1" {
+			t.Errorf("unexpected payload for %s: %v", want.ID, got.meta)
+		}
+	}
+}
+
+func TestIngestionServiceProcessFailure(t *testing.T) {
+	store := &fakeStore{}
+	llm := &fakeLLM{summaryErr: domain.NewErrSummaryGeneration(errors.New("quota"))}
+	svc := newTestIngestion(t, llm, store, testIngestionConfig)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+
+	_, err := svc.Process(context.Background(), doc, "Some text.", domain.IngestOptions{})
+	if !errors.Is(err, domain.ErrSummaryGeneration) {
+		t.Fatalf("expected ErrSummaryGeneration, got %v", err)
+	}
+	if doc.Status != domain.StatusFailed || doc.Error == nil {
+		t.Errorf("document not marked failed: %+v", doc)
+	}
+	if doc.ProcessedAt != nil {
+		t.Errorf("ProcessedAt should stay nil on failure")
+	}
+	if len(store.indexed) != 0 {
+		t.Errorf("nothing should be indexed on failure")
+	}
+}
+
+func TestIngestionServiceReindexDropsStaleChunks(t *testing.T) {
+	ctx := context.Background()
+	store, err := memory.NewMemoryStore("")
+	if err != nil {
+		t.Fatalf("NewMemoryStore failed: %v", err)
+	}
+	for _, name := range []string{"chunks", "summaries"} {
+		if err := store.EnsureCollection(ctx, name, 3, domain.DistanceCosine); err != nil {
+			t.Fatalf("EnsureCollection failed: %v", err)
+		}
+	}
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	ingest := func(store ports.VectorStoreService, raw string) error {
+		_, err := newTestIngestion(t, &fakeLLM{}, store, testIngestionConfig).Process(ctx, doc, raw, domain.IngestOptions{})
+		return err
+	}
+	if err := ingest(store, "One two three. Four five six. Seven eight nine."); err != nil {
+		t.Fatalf("first ingestion failed: %v", err)
+	}
+
+	if err := ingest(store, "One two three. Four five six."); err != nil {
+		t.Fatalf("re-ingestion failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "chunks", "doc1_2"); !errors.Is(err, domain.ErrPointNotFound) {
+		t.Errorf("stale chunk doc1_2 should be gone, got %v", err)
+	}
+	for _, id := range []string{"doc1_0", "doc1_1"} {
+		if _, err := store.Get(ctx, "chunks", id); err != nil {
+			t.Errorf("chunk %s should be indexed: %v", id, err)
+		}
+	}
+}
+
+func TestDocumentPipelineSavesOutcome(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := repository.NewMemoryDocumentRepository()
+	uploader, err := NewFileUploader(t.TempDir(), 0, repo)
+	if err != nil {
+		t.Fatalf("NewFileUploader failed: %v", err)
+	}
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("One two three. Four five six."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := uploader.Upload(ctx, []string{path})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		llm  *fakeLLM
+		want domain.ProcessingStatus
+	}{
+		{"completed", &fakeLLM{}, domain.StatusCompleted},
+		{"failed", &fakeLLM{summaryErr: errors.New("quota")}, domain.StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingestion := newTestIngestion(t, tt.llm, &fakeStore{}, testIngestionConfig)
+			doc, err := repo.Get(ctx, docs[0].ID)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			err = NewDocumentPipeline(ingestion, uploader, repo).Process(ctx, doc, domain.IngestOptions{})
+			if (err != nil) != (tt.want == domain.StatusFailed) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			saved, err := repo.Get(ctx, doc.ID)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if saved.Status != tt.want || (saved.Error != nil) != (tt.want == domain.StatusFailed) {
+				t.Errorf("saved document = %+v, want status %s", saved, tt.want)
+			}
+		})
+	}
+}
+
+// statusReader records the repository status of each document it reads
+type statusReader struct {
+	repo   ports.DocumentRepository
+	text   string
+	err    error
+	status domain.ProcessingStatus
+}
+
+func (r *statusReader) ReadText(doc domain.Document) (string, error) {
+	stored, err := r.repo.Get(context.Background(), doc.ID)
+	if err != nil {
+		return "", err
+	}
+	r.status = stored.Status
+	return r.text, r.err
+}
+
+func TestDocumentPipelineSavesProcessing(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryDocumentRepository()
+	doc := domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	if err := repo.Save(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	reader := &statusReader{repo: repo, text: "One two three. Four five six."}
+	ingestion := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, testIngestionConfig)
+
+	if err := NewDocumentPipeline(ingestion, reader, repo).Process(ctx, doc, domain.IngestOptions{}); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if reader.status != domain.StatusProcessing {
+		t.Errorf("stored status while reading = %s, want %s", reader.status, domain.StatusProcessing)
+	}
+}
+
+func TestDocumentPipelineSavesReadFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryDocumentRepository()
+	doc := domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	if err := repo.Save(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	readErr := errors.New("file gone")
+	reader := &statusReader{repo: repo, err: readErr}
+	ingestion := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, testIngestionConfig)
+
+	if err := NewDocumentPipeline(ingestion, reader, repo).Process(ctx, doc, domain.IngestOptions{}); !errors.Is(err, readErr) {
+		t.Fatalf("Process error = %v, want %v", err, readErr)
+	}
+	saved, err := repo.Get(ctx, doc.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if saved.Status != domain.StatusFailed || saved.Error == nil {
+		t.Errorf("saved document = %+v, want status %s with an error", saved, domain.StatusFailed)
+	}
+}
+
+func TestIngestionServiceChunkingPerIngestion(t *testing.T) {
+	cfg := testIngestionConfig
+	cfg.Chunking = ChunkingFixed
+	cfg.Semantic = SemanticChunking{Percentile: 200} // only rejected when semantic chunking runs
+	svc := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, cfg)
+	const raw = "One two three. Four five six."
+
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	if _, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{}); err != nil {
+		t.Fatalf("Process with the configured strategy failed: %v", err)
+	}
+	doc = &domain.Document{ID: "doc2", Filename: "b.txt", Status: domain.StatusUploaded}
+	if _, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{Chunking: ChunkingSemantic}); err == nil {
+		t.Error("Process ignored the requested semantic chunking")
+	}
+	doc = &domain.Document{ID: "doc3", Filename: "c.txt", Status: domain.StatusUploaded}
+	_, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{Chunking: "paragraphs"})
+	if !errors.Is(err, domain.ErrUnknownChunkingStrategy) || doc.Status != domain.StatusUploaded {
+		t.Errorf("unknown strategy: err = %v, status = %s", err, doc.Status)
+	}
+}
+
+func TestIngestionServiceSentenceOverlapFitsBudget(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	cfg := testIngestionConfig
+	cfg.Overlap = Overlap{Sentences: 2}
+	cfg.Tokenizer = tok
+	svc := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, cfg)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusUploaded}
+	raw := "One two three. Four. Five six. Seven. Eight nine ten. Eleven. Twelve thirteen. Fourteen."
+
+	result, err := svc.Process(context.Background(), doc, raw, domain.IngestOptions{})
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	overlapped := false
+	for _, c := range result.Chunks {
+		if n := tok.CountTokens(c.Text); n > cfg.MaxTokensPerChunk {
+			t.Errorf("chunk %d %q has %d tokens, limit is %d", c.Index, c.Text, n, cfg.MaxTokensPerChunk)
+		}
+		overlapped = overlapped || c.Overlap != nil
+	}
+	if !overlapped {
+		t.Error("no chunk overlaps the one before it")
+	}
+}
+
+func TestIngestionServiceRejectsInvalidTransition(t *testing.T) {
+	svc := newTestIngestion(t, &fakeLLM{}, &fakeStore{}, testIngestionConfig)
+	doc := &domain.Document{ID: "doc1", Filename: "a.txt", Status: domain.StatusProcessing}
+
+	_, err := svc.Process(context.Background(), doc, "Some text.", domain.IngestOptions{})
+	if !errors.Is(err, domain.ErrInvalidStatusTransition) {
+		t.Fatalf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if doc.Status != domain.StatusProcessing {
+		t.Errorf("status changed to %s", doc.Status)
+	}
+}
+
+func TestApplyAnalysisSoftMemberships(t *testing.T) {
+	chunks := []domain.Chunk{{ID: "c0"}, {ID: "c1"}}
+	summary := domain.Summary{ID: "s"}
+	reduced := [][]float32{{0, 0}, {1, 1}, {2, 2}}
+	clusters := []domain.Cluster{
+		{Label: 0, MemberIDs: []string{"0", "1", "2"}, Probabilities: []float64{0.9, 0.3, 0.4}},
+		{Label: 1, MemberIDs: []string{"1", "2"}, Probabilities: []float64{0.7, 0.6}},
+	}
+
+	if err := applyAnalysis(chunks, &summary, reduced, clusters); err != nil {
+		t.Fatalf("applyAnalysis failed: %v", err)
+	}
+	if !reflect.DeepEqual(chunks[0].ClusterIDs, []int{0}) || !reflect.DeepEqual(chunks[0].ClusterProbabilities, []float64{0.9}) {
+		t.Errorf("chunk 0 memberships = %v %v", chunks[0].ClusterIDs, chunks[0].ClusterProbabilities)
+	}
+	if !reflect.DeepEqual(chunks[1].ClusterIDs, []int{1, 0}) || !reflect.DeepEqual(chunks[1].ClusterProbabilities, []float64{0.7, 0.3}) {
+		t.Errorf("chunk 1 memberships should be ordered by probability: %v %v", chunks[1].ClusterIDs, chunks[1].ClusterProbabilities)
+	}
+	if summary.ClusterID == nil || *summary.ClusterID != 1 {
+		t.Errorf("summary should take its most probable cluster, got %v", summary.ClusterID)
+	}
+
+	points, err := qdrant.MapToQdrantPoints(chunks, [][]float32{{1}, {2}}, [][]float64{{0, 0}, {1, 1}})
+	if err != nil {
+		t.Fatalf("MapToQdrantPoints failed: %v", err)
+	}
+	if got := points[1].Payload["clusterProbabilities"]; !reflect.DeepEqual(got, []float64{0.7, 0.3}) {
+		t.Errorf("payload clusterProbabilities = %v", got)
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// headingPathSep joins heading titles in Segment.HeadingPath
+const headingPathSep = " > "
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockList
+	blockCode
+	blockTable
+	blockBreak
+)
+
+// mdBlock is a top-level markdown block. Code blocks and tables are never split.
+type mdBlock struct {
+	kind  blockKind
+	span  span
+	text  string
+	level int    // heading level, for blockHeading
+	title string // heading text, for blockHeading
+	items []span // list items, for blockList
+}
+
+var (
+	atxHeadingRe    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	listItemRe      = regexp.MustCompile(`^ {0,3}(?:[-*+]|\d{1,9}[.)])(?:[ \t]|$)`)
+	tableDelimRe    = regexp.MustCompile(`^ *\|? *:?-+:? *(?:\| *:?-+:? *)*\|? *$`)
+	setextH1Re      = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	setextH2Re      = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	thematicBreakRe = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	codeFenceRe     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	markdownSuffix  = map[string]bool{".md": true, ".markdown": true}
+)
+
+// IsMarkdown reports whether filename names a markdown document
+func IsMarkdown(filename string) bool {
+	return markdownSuffix[strings.ToLower(filepath.Ext(filename))]
+}
+
+// SegmentMarkdown splits a markdown document into segments of at most maxTokens, as counted by tok.
+// Segments never span two heading sections or a thematic break, and record the path of headings
+// enclosing them; heading lines open the first segment of their section and count against its
+// budget. Fenced code blocks and tables are kept whole, so one larger than maxTokens becomes a
+// segment of its own; oversized lists are split between items and oversized paragraphs between
+// sentences.
+func SegmentMarkdown(raw string, maxTokens int, tok ports.Tokenizer) ([]Segment, error) {
+	if maxTokens <= 0 {
+		return nil, fmt.Errorf("maxTokens must be > 0")
+	}
+	lang := DetectLanguage(raw)
+
+	lines := newLineIndex(raw)
+
+	type heading struct {
+		level int
+		title string
+	}
+	var (
+		segments  []Segment
+		path      []heading
+		curr      span
+		currCount int
+		hasBody   bool
+		hasAny    bool
+	)
+	headingPath := func() string {
+		titles := make([]string, len(path))
+		for i, h := range path {
+			titles[i] = h.title
+		}
+		return strings.Join(titles, headingPathSep)
+	}
+	flush := func() {
+		if hasAny {
+			segments = append(segments, Segment{Text: raw[curr.start:curr.end], Span: lines.locate(curr), HeadingPath: headingPath()})
+		}
+		currCount, hasBody, hasAny = 0, false, false
+	}
+	extend := func(sp span, n int) {
+		if !hasAny {
+			curr.start = sp.start
+		}
+		curr.end = sp.end
+		currCount += n
+		hasAny = true
+	}
+	add := func(sp span) {
+		n := tok.CountTokens(raw[sp.start:sp.end])
+		if hasAny && currCount+n > maxTokens {
+			flush()
+		}
+		extend(sp, n)
+		hasBody = true
+	}
+	// room returns the budget left beside the heading lines opening the current segment, which
+	// splittable blocks are cut to. Headings leaving no room are flushed as a segment of their own.
+	room := func() int {
+		if hasBody {
+			return maxTokens
+		}
+		if currCount >= maxTokens {
+			flush()
+			return maxTokens
+		}
+		return maxTokens - currCount
+	}
+
+	for _, b := range parseMarkdownBlocks(raw) {
+		switch b.kind {
+		case blockHeading:
+			// A heading directly followed by a subheading stays with it rather than
+			// becoming a chunk of its own
+			if hasBody {
+				flush()
+			}
+			for len(path) > 0 && path[len(path)-1].level >= b.level {
+				path = path[:len(path)-1]
+			}
+			path = append(path, heading{level: b.level, title: b.title})
+			extend(b.span, tok.CountTokens(b.text))
+		case blockBreak:
+			// The break itself belongs to no segment; headings above it stay with the text below
+			if hasBody {
+				flush()
+			}
+		case blockCode, blockTable:
+			add(b.span)
+		case blockList:
+			limit := room()
+			if tok.CountTokens(b.text) <= limit {
+				add(b.span)
+				continue
+			}
+			for _, item := range b.items {
+				if tok.CountTokens(raw[item.start:item.end]) <= limit {
+					add(item)
+					continue
+				}
+				for _, piece := range proseSpans(raw, item, lang, limit, tok) {
+					add(piece)
+				}
+			}
+		default:
+			limit := room()
+			if tok.CountTokens(b.text) <= limit {
+				add(b.span)
+				continue
+			}
+			for _, piece := range proseSpans(raw, b.span, lang, limit, tok) {
+				add(piece)
+			}
+		}
+	}
+	flush()
+	return segments, nil
+}
+
+// parseMarkdownBlocks splits raw into top-level blocks: ATX and setext headings, fenced code,
+// pipe tables, lists, thematic breaks and paragraphs. As in CommonMark, a line of dashes right
+// under a paragraph line underlines a setext heading rather than breaking.
+func parseMarkdownBlocks(raw string) []mdBlock {
+	// Lines are matched without their "\r", but spans always index raw
+	lines := strings.Split(raw, "\n")
+	starts := make([]int, len(lines))
+	pos := 0
+	for i, line := range lines {
+		starts[i] = pos
+		pos += len(line) + 1
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	// block returns a block covering lines [from, to), trimmed of surrounding whitespace
+	block := func(kind blockKind, from, to int) mdBlock {
+		sp := trimSpan(raw, span{starts[from], starts[to-1] + len(lines[to-1])})
+		return mdBlock{kind: kind, span: sp, text: raw[sp.start:sp.end]}
+	}
+
+	var blocks []mdBlock
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case codeFenceRe.MatchString(line):
+			fence := codeFenceRe.FindStringSubmatch(line)[1]
+			end := i + 1
+			for end < len(lines) && !closesFence(lines[end], fence) {
+				end++
+			}
+			// An unclosed fence runs to the end of the document
+			end = min(end+1, len(lines))
+			blocks = append(blocks, block(blockCode, i, end))
+			i = end
+		case thematicBreakRe.MatchString(line):
+			blocks = append(blocks, block(blockBreak, i, i+1))
+			i++
+		case atxHeadingRe.MatchString(line):
+			m := atxHeadingRe.FindStringSubmatch(line)
+			b := block(blockHeading, i, i+1)
+			b.level, b.title = len(m[1]), strings.TrimSpace(m[2])
+			blocks = append(blocks, b)
+			i++
+		case isTableStart(lines, i):
+			end := i + 2
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" && strings.Contains(lines[end], "|") {
+				end++
+			}
+			blocks = append(blocks, block(blockTable, i, end))
+			i = end
+		case listItemRe.MatchString(line):
+			end, itemStarts := parseList(lines, i)
+			b := block(blockList, i, end)
+			for j, from := range itemStarts {
+				to := end
+				if j+1 < len(itemStarts) {
+					to = itemStarts[j+1]
+				}
+				b.items = append(b.items, block(blockList, from, to).span)
+			}
+			blocks = append(blocks, b)
+			i = end
+		case i+1 < len(lines) && (setextH1Re.MatchString(lines[i+1]) || setextH2Re.MatchString(lines[i+1])):
+			b := block(blockHeading, i, i+2)
+			b.level, b.title = 2, strings.TrimSpace(line)
+			if setextH1Re.MatchString(lines[i+1]) {
+				b.level = 1
+			}
+			blocks = append(blocks, b)
+			i += 2
+		default:
+			end := i + 1
+			for end < len(lines) && !startsBlock(lines, end) {
+				end++
+			}
+			blocks = append(blocks, block(blockParagraph, i, end))
+			i = end
+		}
+	}
+	return blocks
+}
+
+// parseList consumes a list starting at line i, including indented continuation lines and
+// items separated by blank lines. It returns the index of the line after the list and the
+// first line of each item.
+func parseList(lines []string, i int) (int, []int) {
+	var itemStarts []int
+	end := i
+loop:
+	for ; end < len(lines); end++ {
+		line := lines[end]
+		switch {
+		case thematicBreakRe.MatchString(line):
+			break loop
+		case listItemRe.MatchString(line):
+			itemStarts = append(itemStarts, end)
+		case strings.TrimSpace(line) == "":
+			// A blank line continues the list only if more of it follows
+			if end+1 >= len(lines) || !(listItemRe.MatchString(lines[end+1]) || isIndented(lines[end+1])) {
+				break loop
+			}
+		case isIndented(line) || !startsBlock(lines, end):
+		default:
+			break loop
+		}
+	}
+	return end, itemStarts
+}
+
+// trimSpan narrows sp to exclude leading and trailing whitespace of raw
+func trimSpan(raw string, sp span) span {
+	text := raw[sp.start:sp.end]
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	sp.start += len(text) - len(trimmed)
+	sp.end = sp.start + len(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+	return sp
+}
+
+// startsBlock reports whether line i begins a block other than a paragraph continuation
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+	return strings.TrimSpace(line) == "" ||
+		codeFenceRe.MatchString(line) ||
+		thematicBreakRe.MatchString(line) ||
+		atxHeadingRe.MatchString(line) ||
+		listItemRe.MatchString(line) ||
+		isTableStart(lines, i)
+}
+
+// isTableStart reports whether a pipe table (header row plus delimiter row) starts at line i
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") &&
+		strings.Contains(lines[i+1], "-") && tableDelimRe.MatchString(lines[i+1])
+}
+
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")
+}
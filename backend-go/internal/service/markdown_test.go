@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+const testMarkdown = "Preface text.\n" +
+	"\n" +
+	"# Intro\n" +
+	"\n" +
+	"## Setup\n" +
+	"\n" +
+	"Install the tool. Then run it.\n" +
+	"\n" +
+	"```go\n" +
+	"func main() {\n" +
+	"\n" +
+	"\tfmt.Println(\"# not a heading\")\n" +
+	"}\n" +
+	"```\n" +
+	"\n" +
+	"| a | b |\n" +
+	"|---|---|\n" +
+	"| 1 | 2 |\n" +
+	"\n" +
+	"## Usage\n" +
+	"\n" +
+	"- first item here\n" +
+	"- second item here\n" +
+	"  continued\n" +
+	"\n" +
+	"Usage Notes\n" +
+	"===========\n" +
+	"\n" +
+	"Closing words."
+
+func TestParseMarkdownBlocks(t *testing.T) {
+	var kinds []blockKind
+	for _, b := range parseMarkdownBlocks(testMarkdown) {
+		kinds = append(kinds, b.kind)
+	}
+	want := []blockKind{blockParagraph, blockHeading, blockHeading, blockParagraph, blockCode, blockTable, blockHeading, blockList, blockHeading, blockParagraph}
+	if len(kinds) != len(want) {
+		t.Fatalf("got block kinds %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("block %d kind = %v, want %v", i, kinds[i], want[i])
+		}
+	}
+}
+
+func TestSegmentMarkdown(t *testing.T) {
+	segments, err := SegmentMarkdown(testMarkdown, 200, tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	var paths []string
+	for _, s := range segments {
+		paths = append(paths, s.HeadingPath)
+	}
+	want := []string{"", "Intro > Setup", "Intro > Usage", "Usage Notes"}
+	if strings.Join(paths, "|") != strings.Join(want, "|") {
+		t.Fatalf("heading paths = %q, want %q", paths, want)
+	}
+	setup := segments[1].Text
+	if !strings.HasPrefix(setup, "# Intro\n\n## Setup") || !strings.Contains(setup, "```go\nfunc main() {\n\n\tfmt.Println") {
+		t.Errorf("setup section lost its headings or code block:\n%s", setup)
+	}
+}
+
+func TestSegmentMarkdownSplitsOversizedSections(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	code := "```\nline one two three four five six\n```"
+	doc := "# Big\n\nOne two three. Four five six. Seven eight nine.\n\n" + code + "\n\n- a b c\n- d e f\n- g h i"
+	segments, err := SegmentMarkdown(doc, 5, tok)
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	var codeSegments int
+	for _, s := range segments {
+		if s.HeadingPath != "Big" {
+			t.Errorf("segment %q has heading path %q", s.Text, s.HeadingPath)
+		}
+		if strings.Contains(s.Text, "```") {
+			codeSegments++
+			if !strings.Contains(s.Text, code) {
+				t.Errorf("code block was split: %q", s.Text)
+			}
+			continue
+		}
+		if n := tok.CountTokens(s.Text); n > 5 {
+			t.Errorf("segment %q has %d tokens, over budget", s.Text, n)
+		}
+	}
+	if codeSegments != 1 {
+		t.Errorf("expected the code block in exactly one segment, got %d", codeSegments)
+	}
+	if last := segments[len(segments)-1].Text; last != "- g h i" {
+		t.Errorf("oversized list should be split between items, last segment = %q", last)
+	}
+}
+
+func TestSegmentMarkdownCountsHeadings(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	doc := "# A long title\n\n## Sub\n\nOne two three four. Five six.\n\n```\nx\n```"
+	segments, err := SegmentMarkdown(doc, 8, tok)
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	for _, s := range segments {
+		if n := tok.CountTokens(s.Text); n > 8 {
+			t.Errorf("segment %q has %d tokens, over budget", s.Text, n)
+		}
+	}
+	if first := segments[0].Text; !strings.HasPrefix(first, "# A long title\n\n## Sub\n\nOne") {
+		t.Errorf("headings should open the first segment of their section, got %q", first)
+	}
+}
+
+func TestSegmentMarkdownThematicBreaks(t *testing.T) {
+	doc := "First part.\n\n---\n\nSecond part.\n***\nThird part.\n\n- item\n\n* * *\n\nSetext\n---\n\nLast."
+	var kinds []blockKind
+	for _, b := range parseMarkdownBlocks(doc) {
+		kinds = append(kinds, b.kind)
+	}
+	want := []blockKind{blockParagraph, blockBreak, blockParagraph, blockBreak, blockParagraph, blockList, blockBreak, blockHeading, blockParagraph}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Fatalf("got block kinds %v, want %v", kinds, want)
+	}
+
+	segments, err := SegmentMarkdown(doc, 200, tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	var texts []string
+	for _, s := range segments {
+		texts = append(texts, s.Text)
+	}
+	wantTexts := []string{"First part.", "Second part.", "Third part.\n\n- item", "Setext\n---\n\nLast."}
+	if strings.Join(texts, "|") != strings.Join(wantTexts, "|") {
+		t.Errorf("segments = %q, want %q", texts, wantTexts)
+	}
+}
+
+func TestSegmentMarkdownSpans(t *testing.T) {
+	raw := strings.ReplaceAll(testMarkdown, "\n", "\r\n")
+	segments, err := SegmentMarkdown(raw, 200, tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("SegmentMarkdown failed: %v", err)
+	}
+	for i, s := range segments {
+		if raw[s.Span.StartByte:s.Span.EndByte] != s.Text {
+			t.Errorf("segment %d span %+v does not cover its text", i, s.Span)
+		}
+	}
+	if sp := segments[1].Span; sp.StartLine != 3 || sp.EndLine != 18 {
+		t.Errorf("setup section lines = %d-%d, want 3-18", sp.StartLine, sp.EndLine)
+	}
+	if last := segments[len(segments)-1]; last.Span.EndByte != len(raw) || last.Span.EndLine != 29 {
+		t.Errorf("last segment span = %+v, want it to end the document on line 29", last.Span)
+	}
+}
+
+func TestIsMarkdown(t *testing.T) {
+	if !IsMarkdown("notes.MD") || !IsMarkdown("a.markdown") || IsMarkdown("a.txt") {
+		t.Error("IsMarkdown misclassified file names")
+	}
+}
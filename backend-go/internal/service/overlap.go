@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// Overlap configures a sliding window over segments: each segment is prefixed with the end of
+// the one before it, measured in whole sentences or in tokens. At most one of the two may be set.
+type Overlap struct {
+	Tokens    int
+	Sentences int
+}
+
+// Validate checks that the overlap is usable with the given chunk budget
+func (o Overlap) Validate(maxTokens int) error {
+	switch {
+	case o.Tokens < 0 || o.Sentences < 0:
+		return fmt.Errorf("overlap must not be negative")
+	case o.Tokens > 0 && o.Sentences > 0:
+		return fmt.Errorf("overlap may be set in tokens or in sentences, not both")
+	case o.Tokens >= maxTokens:
+		return fmt.Errorf("overlap of %d tokens must be smaller than the %d token chunk budget", o.Tokens, maxTokens)
+	}
+	return nil
+}
+
+// enabled reports whether any overlap is configured
+func (o Overlap) enabled() bool {
+	return o.Tokens > 0 || o.Sentences > 0
+}
+
+// ApplyOverlap extends each segment of raw backwards over the tail of the previous one and records
+// the length of that tail in Segment.Overlap; the text between the two segments, usually a blank
+// line, follows it in Text but is not counted. Text stays a verbatim slice of raw and Span grows
+// to cover the prefix. Segments under different headings are not overlapped. A token overlap takes
+// trailing sentences while they fit, then trailing words (characters in CJK text) when even the
+// last sentence is too long. A sentence overlap takes as many of its sentences as keep the
+// overlapped segment within maxTokens, and none when even one would not fit.
+func ApplyOverlap(raw string, segments []Segment, overlap Overlap, maxTokens int, lang Language, tok ports.Tokenizer) []Segment {
+	if !overlap.enabled() || len(segments) < 2 {
+		return segments
+	}
+	lines := newLineIndex(raw)
+	out := make([]Segment, len(segments))
+	out[0] = segments[0]
+	for i := 1; i < len(segments); i++ {
+		out[i] = segments[i]
+		prev := segments[i-1]
+		if prev.HeadingPath != segments[i].HeadingPath {
+			continue
+		}
+		before := spanOf(prev)
+		curr := spanOf(segments[i])
+		var start int
+		if overlap.Sentences > 0 {
+			start = before.end
+			for n := overlap.Sentences; n > 0; n-- {
+				candidate := tailSentences(raw, before, n, lang)
+				if candidate < curr.start && tok.CountTokens(raw[candidate:curr.end]) <= maxTokens {
+					start = candidate
+					break
+				}
+			}
+		} else {
+			start = tailTokens(raw, before, overlap.Tokens, lang, tok)
+		}
+		// Nothing of the previous segment fits: the gap between the two is not an overlap
+		if start >= before.end || start >= curr.start {
+			continue
+		}
+		out[i].Text = raw[start:curr.end]
+		out[i].Span = lines.locate(span{start, curr.end})
+		out[i].Overlap = before.end - start
+	}
+	return out
+}
+
+// ownSpan is the span of seg without its overlap prefix and the whitespace separating that
+// prefix from seg's own text
+func ownSpan(raw string, seg Segment) span {
+	sp := span{seg.Span.StartByte + seg.Overlap, seg.Span.EndByte}
+	if seg.Overlap > 0 {
+		for sp.start < sp.end {
+			r, size := utf8.DecodeRuneInString(raw[sp.start:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			sp.start += size
+		}
+	}
+	return sp
+}
+
+// tailSentences returns the start offset of the last n sentences of raw[within]
+func tailSentences(raw string, within span, n int, lang Language) int {
+	sentences := sentenceSpans(raw[within.start:within.end], lang)
+	if len(sentences) == 0 {
+		return within.end
+	}
+	if n > len(sentences) {
+		n = len(sentences)
+	}
+	return within.start + sentences[len(sentences)-n].start
+}
+
+// tailTokens returns the start offset of the longest suffix of raw[within], cut at a sentence or
+// word boundary, of at most maxTokens
+func tailTokens(raw string, within span, maxTokens int, lang Language, tok ports.Tokenizer) int {
+	start := within.end
+	sentences := sentenceSpans(raw[within.start:within.end], lang)
+	for i := len(sentences) - 1; i >= 0; i-- {
+		candidate := within.start + sentences[i].start
+		if tok.CountTokens(raw[candidate:within.end]) > maxTokens {
+			break
+		}
+		start = candidate
+	}
+	if start < within.end {
+		return start
+	}
+	// Even the last sentence is too long: back off to words, or characters for CJK
+	var starts []int
+	if lang.IsCJK() {
+		for i := range raw[within.start:within.end] {
+			starts = append(starts, within.start+i)
+		}
+	} else {
+		for _, word := range fieldSpans(raw, within) {
+			starts = append(starts, word.start)
+		}
+	}
+	for i := len(starts) - 1; i >= 0; i-- {
+		if tok.CountTokens(raw[starts[i]:within.end]) > maxTokens {
+			break
+		}
+		start = starts[i]
+	}
+	return start
+}
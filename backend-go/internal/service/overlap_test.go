@@ -0,0 +1,136 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+func TestApplyOverlap(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	raw := "One two. Three four five. Six seven. Eight. Nine."
+	segments := segmentsOf(raw, "One two. Three four five.", "Six seven. Eight.", "Nine.")
+	segments[2].HeadingPath = "Other"
+	tests := []struct {
+		name    string
+		overlap Overlap
+		want    []string
+	}{
+		{"none", Overlap{}, []string{"One two. Three four five.", "Six seven. Eight.", "Nine."}},
+		{"one sentence", Overlap{Sentences: 1}, []string{"One two. Three four five.", "Three four five. Six seven. Eight.", "Nine."}},
+		{"tokens fit a sentence", Overlap{Tokens: 4}, []string{"One two. Three four five.", "Three four five. Six seven. Eight.", "Nine."}},
+		{"tokens fall back to words", Overlap{Tokens: 2}, []string{"One two. Three four five.", "four five. Six seven. Eight.", "Nine."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyOverlap(raw, segments, tt.overlap, 1000, LanguageEnglish, tok)
+			for i := range got {
+				if got[i].Text != tt.want[i] {
+					t.Errorf("segment %d = %q, want %q", i, got[i].Text, tt.want[i])
+				}
+				if o := got[i].Overlap; o > 0 && (!strings.HasSuffix(segments[i-1].Text, got[i].Text[:o]) ||
+					strings.TrimSpace(got[i].Text[o:]) != segments[i].Text) {
+					t.Errorf("segment %d overlap %d does not mark the repeated prefix", i, o)
+				}
+				if sp := got[i].Span; raw[sp.StartByte:sp.EndByte] != got[i].Text {
+					t.Errorf("segment %d span %+v does not cover its text", i, sp)
+				}
+			}
+			if got[2].Overlap != 0 {
+				t.Error("segments under a different heading must not overlap")
+			}
+		})
+	}
+}
+
+// wordyTokenizer counts every text as far too long for any overlap budget
+type wordyTokenizer struct{}
+
+func (wordyTokenizer) CountTokens(text string) int { return 1000 }
+
+func TestApplyOverlapSentencesFitBudget(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	raw := "One two. Three four five. Six seven. Eight."
+	segments := segmentsOf(raw, "One two. Three four five.", "Six seven. Eight.")
+	tests := []struct {
+		maxTokens int
+		want      string
+	}{
+		{9, "One two. Three four five. Six seven. Eight."},
+		{6, "Three four five. Six seven. Eight."},
+		{5, "Six seven. Eight."},
+	}
+	for _, tt := range tests {
+		got := ApplyOverlap(raw, segments, Overlap{Sentences: 2}, tt.maxTokens, LanguageEnglish, tok)
+		if got[1].Text != tt.want {
+			t.Errorf("maxTokens %d: segment = %q, want %q", tt.maxTokens, got[1].Text, tt.want)
+		}
+		if n := tok.CountTokens(got[1].Text); n > tt.maxTokens {
+			t.Errorf("maxTokens %d: segment has %d tokens", tt.maxTokens, n)
+		}
+	}
+}
+
+func TestApplyOverlapNothingFits(t *testing.T) {
+	raw := "```\ncode\n```\n\nNext paragraph."
+	segments := segmentsOf(raw, "```\ncode\n```", "Next paragraph.")
+	got := ApplyOverlap(raw, segments, Overlap{Tokens: 4}, 1000, LanguageEnglish, wordyTokenizer{})
+	if got[1].Overlap != 0 || got[1].Text != "Next paragraph." {
+		t.Errorf("the gap between segments was taken as overlap: %q (%d)", got[1].Text, got[1].Overlap)
+	}
+}
+
+func TestApplyOverlapJapanese(t *testing.T) {
+	raw := "一つ目の文。二つ目の文。三つ目の文。"
+	segments := segmentsOf(raw, "一つ目の文。二つ目の文。", "三つ目の文。")
+	got := ApplyOverlap(raw, segments, Overlap{Sentences: 1}, 1000, LanguageJapanese, tokenizer.NewCharTokenizer())
+	if got[1].Text != "二つ目の文。三つ目の文。" || got[1].Text[:got[1].Overlap] != "二つ目の文。" {
+		t.Errorf("unexpected overlap: %q (%d)", got[1].Text, got[1].Overlap)
+	}
+}
+
+func TestOverlapValidate(t *testing.T) {
+	if err := (Overlap{Tokens: 1, Sentences: 1}).Validate(10); err == nil {
+		t.Error("expected error when both units are set")
+	}
+	if err := (Overlap{Tokens: 10}).Validate(10); err == nil {
+		t.Error("expected error when overlap fills the whole budget")
+	}
+	if err := (Overlap{Sentences: 2}).Validate(10); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildChunksRecordsOverlap(t *testing.T) {
+	raw := "A b. C d.\nE f."
+	segments := ApplyOverlap(raw, segmentsOf(raw, "A b. C d.", "E f."), Overlap{Sentences: 1}, 1000, LanguageEnglish, tokenizer.NewCharTokenizer())
+	chunks, err := BuildChunks("doc", segments, make([][]string, 2), tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("BuildChunks failed: %v", err)
+	}
+	if chunks[0].Overlap != nil {
+		t.Errorf("first chunk has no predecessor, got overlap %+v", chunks[0].Overlap)
+	}
+	o := chunks[1].Overlap
+	if chunks[1].ID != "doc_1" || chunks[1].Index != 1 || o == nil || chunks[1].Text[o.Start:o.End] != "C d." {
+		t.Errorf("unexpected second chunk: %+v overlap %+v", chunks[1], o)
+	}
+	if want := (domain.SourceSpan{StartByte: 5, EndByte: 14, StartLine: 1, EndLine: 2}); chunks[1].Span != want {
+		t.Errorf("second chunk span = %+v, want %+v", chunks[1].Span, want)
+	}
+}
+
+// segmentsOf locates each of texts in raw, in order, as a segment
+func segmentsOf(raw string, texts ...string) []Segment {
+	lines := newLineIndex(raw)
+	var segments []Segment
+	pos := 0
+	for _, text := range texts {
+		start := pos + strings.Index(raw[pos:], text)
+		pos = start + len(text)
+		segments = append(segments, Segment{Text: text, Span: lines.locate(span{start, pos})})
+	}
+	return segments
+}
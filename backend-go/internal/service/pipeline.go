@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// textReader returns the stored content of an uploaded document
+type textReader interface {
+	ReadText(doc domain.Document) (string, error)
+}
+
+// DocumentPipeline implements ports.DocumentProcessor: it ingests uploaded documents from
+// their stored text and saves each one's outcome to the repository.
+type DocumentPipeline struct {
+	ingestion *IngestionService
+	texts     textReader
+	repo      ports.DocumentRepository
+}
+
+// NewDocumentPipeline creates a pipeline reading documents through texts, e.g. a FileUploader
+func NewDocumentPipeline(ingestion *IngestionService, texts textReader, repo ports.DocumentRepository) *DocumentPipeline {
+	return &DocumentPipeline{ingestion: ingestion, texts: texts, repo: repo}
+}
+
+// Process ingests doc, saving it once it is processing and again once it has completed or
+// failed with its error, so the repository never keeps a document stuck in an earlier status
+func (p *DocumentPipeline) Process(ctx context.Context, doc domain.Document, opts domain.IngestOptions) error {
+	if err := startProcessing(&doc, opts); err != nil {
+		return err
+	}
+	if err := p.save(ctx, doc); err != nil {
+		return err
+	}
+
+	raw, err := p.texts.ReadText(doc)
+	if err != nil {
+		err = fmt.Errorf("failed to read document %s: %w", doc.ID, err)
+		if tErr := failProcessing(&doc, err); tErr != nil {
+			return tErr
+		}
+		if sErr := p.save(ctx, doc); sErr != nil {
+			return sErr
+		}
+		return err
+	}
+	_, runErr := p.ingestion.ingest(ctx, &doc, raw, opts)
+	if err := p.save(ctx, doc); err != nil {
+		return err
+	}
+	return runErr
+}
+
+func (p *DocumentPipeline) save(ctx context.Context, doc domain.Document) error {
+	if err := p.repo.Save(ctx, doc); err != nil {
+		return fmt.Errorf("failed to save document %s: %w", doc.ID, err)
+	}
+	return nil
+}
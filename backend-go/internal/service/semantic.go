@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// ChunkingStrategy selects how plain-text documents are cut into segments
+type ChunkingStrategy = domain.ChunkingStrategy
+
+const (
+	// ChunkingFixed packs sentences into windows of up to the token budget (SegmentText).
+	ChunkingFixed = domain.ChunkingFixed
+	// ChunkingSemantic cuts where the topic shifts between sentences (SegmentSemantic).
+	ChunkingSemantic = domain.ChunkingSemantic
+)
+
+// SemanticChunking configures SegmentSemantic.
+// A boundary is placed between two sentences when their similarity is below the Percentile-th
+// percentile of all adjacent-sentence similarities in the document, so a lower Percentile gives
+// fewer, larger chunks. Chunks are not cut on a topic shift until they reach MinTokens.
+type SemanticChunking struct {
+	Percentile float64
+	MinTokens  int
+}
+
+// Validate checks that the options are usable with the given chunk budget
+func (o SemanticChunking) Validate(maxTokens int) error {
+	switch {
+	case o.Percentile < 0 || o.Percentile > 100:
+		return fmt.Errorf("semantic percentile must be within [0, 100], got %v", o.Percentile)
+	case o.MinTokens < 0:
+		return fmt.Errorf("semantic min tokens must not be negative")
+	case o.MinTokens > maxTokens:
+		return fmt.Errorf("semantic min tokens %d exceed the %d token chunk budget", o.MinTokens, maxTokens)
+	}
+	return nil
+}
+
+// SegmentSemantic splits raw into segments of at most maxTokens, as counted by tok, placing
+// boundaries where consecutive sentences are least similar according to embedder. Sentences
+// longer than maxTokens are split at word boundaries first, as in SegmentText. A trailing segment
+// shorter than MinTokens is merged into the one before it when the budget allows.
+func SegmentSemantic(ctx context.Context, raw string, maxTokens int, opts SemanticChunking, embedder ports.EmbeddingModel, tok ports.Tokenizer) ([]Segment, error) {
+	if maxTokens <= 0 {
+		return nil, fmt.Errorf("maxTokens must be > 0")
+	}
+	if err := opts.Validate(maxTokens); err != nil {
+		return nil, err
+	}
+	lang := DetectLanguage(raw)
+	var units []span
+	for _, sp := range sentenceSpans(raw, lang) {
+		if tok.CountTokens(raw[sp.start:sp.end]) > maxTokens {
+			units = append(units, wordSpans(raw, sp, maxTokens, tok)...)
+			continue
+		}
+		units = append(units, sp)
+	}
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	similarities, err := adjacentSimilarities(ctx, raw, units, embedder)
+	if err != nil {
+		return nil, err
+	}
+	threshold := percentile(similarities, opts.Percentile)
+
+	var packed []span
+	var counts []int
+	curr, currCount := units[0], tok.CountTokens(raw[units[0].start:units[0].end])
+	for i, unit := range units[1:] {
+		n := tok.CountTokens(raw[unit.start:unit.end])
+		shift := similarities[i] < threshold && currCount >= opts.MinTokens
+		if shift || currCount+n > maxTokens {
+			packed, counts = append(packed, curr), append(counts, currCount)
+			curr, currCount = unit, n
+			continue
+		}
+		curr.end = unit.end
+		currCount += n
+	}
+	if last := len(packed) - 1; last >= 0 && currCount < opts.MinTokens && counts[last]+currCount <= maxTokens {
+		packed[last].end = curr.end
+	} else {
+		packed = append(packed, curr)
+	}
+
+	lines := newLineIndex(raw)
+	segments := make([]Segment, len(packed))
+	for i, sp := range packed {
+		segments[i] = Segment{Text: raw[sp.start:sp.end], Span: lines.locate(sp)}
+	}
+	return segments, nil
+}
+
+// adjacentSimilarities embeds units and returns the cosine similarity of each unit to the next
+func adjacentSimilarities(ctx context.Context, raw string, units []span, embedder ports.EmbeddingModel) ([]float64, error) {
+	if len(units) < 2 {
+		return nil, nil
+	}
+	texts := make([]string, len(units))
+	for i, sp := range units {
+		texts[i] = raw[sp.start:sp.end]
+	}
+	vectors, err := embedder.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sentences: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding count mismatch: expected %d, got %d", len(texts), len(vectors))
+	}
+	similarities := make([]float64, len(units)-1)
+	for i := range similarities {
+		similarities[i] = cosineSimilarity(vectors[i], vectors[i+1])
+	}
+	return similarities, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 if either is zero
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// percentile returns the p-th percentile of values with linear interpolation between ranks
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+// topicEmbedder embeds a text along the axis of the first topic word it contains
+type topicEmbedder struct {
+	fakeEmbedder
+	calls int
+}
+
+func (e *topicEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := []float32{0.1, 0.1, 0.1}
+		for axis, topic := range []string{"cat", "stock", "rain"} {
+			if strings.Contains(strings.ToLower(text), topic) {
+				v[axis] = 1
+				break
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+const topicText = "The cat sleeps. A cat purrs softly. Stock prices fell. The stock market closed. Rain is coming. Heavy rain today."
+
+func TestSegmentSemantic(t *testing.T) {
+	embedder := &topicEmbedder{}
+	segments, err := SegmentSemantic(context.Background(), topicText, 50, SemanticChunking{Percentile: 50}, embedder, tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("SegmentSemantic failed: %v", err)
+	}
+	want := []string{"The cat sleeps. A cat purrs softly.", "Stock prices fell. The stock market closed.", "Rain is coming. Heavy rain today."}
+	if len(segments) != len(want) {
+		t.Fatalf("got %d segments %+v, want %d", len(segments), segments, len(want))
+	}
+	for i, seg := range segments {
+		if seg.Text != want[i] {
+			t.Errorf("segment %d = %q, want %q", i, seg.Text, want[i])
+		}
+		if topicText[seg.Span.StartByte:seg.Span.EndByte] != seg.Text {
+			t.Errorf("segment %d span %+v does not cover its text", i, seg.Span)
+		}
+	}
+	if embedder.calls != 1 {
+		t.Errorf("expected sentences to be embedded in one batch, got %d calls", embedder.calls)
+	}
+}
+
+func TestSegmentSemanticTokenBounds(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	t.Run("max tokens forces a cut", func(t *testing.T) {
+		segments, err := SegmentSemantic(context.Background(), topicText, 4, SemanticChunking{}, &topicEmbedder{}, tok)
+		if err != nil {
+			t.Fatalf("SegmentSemantic failed: %v", err)
+		}
+		for _, seg := range segments {
+			if n := tok.CountTokens(seg.Text); n > 4 {
+				t.Errorf("segment %q has %d tokens, over budget", seg.Text, n)
+			}
+		}
+	})
+	t.Run("min tokens suppresses cuts", func(t *testing.T) {
+		segments, err := SegmentSemantic(context.Background(), topicText, 16, SemanticChunking{Percentile: 50, MinTokens: 8}, &topicEmbedder{}, tok)
+		if err != nil {
+			t.Fatalf("SegmentSemantic failed: %v", err)
+		}
+		want := []string{"The cat sleeps. A cat purrs softly. Stock prices fell. The stock market closed.", "Rain is coming. Heavy rain today."}
+		if len(segments) != 2 || segments[0].Text != want[0] || segments[1].Text != want[1] {
+			t.Errorf("unexpected segments %+v", segments)
+		}
+	})
+	t.Run("invalid options", func(t *testing.T) {
+		if _, err := SegmentSemantic(context.Background(), topicText, 5, SemanticChunking{MinTokens: 6}, &topicEmbedder{}, tok); err == nil {
+			t.Error("expected error for min tokens above the budget")
+		}
+		if _, err := SegmentSemantic(context.Background(), topicText, 5, SemanticChunking{Percentile: 101}, &topicEmbedder{}, tok); err == nil {
+			t.Error("expected error for a percentile above 100")
+		}
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{0.4, 0.1, 0.3, 0.2}
+	for p, want := range map[float64]float64{0: 0.1, 50: 0.25, 100: 0.4} {
+		if got := percentile(values, p); got < want-1e-9 || got > want+1e-9 {
+			t.Errorf("percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import "github.com/ran/demo/backend-go/internal/text"
+
+// Language is the dominant script family of a text, as far as sentence splitting cares
+type Language = text.Language
+
+const (
+	LanguageEnglish  = text.LanguageEnglish
+	LanguageJapanese = text.LanguageJapanese
+	LanguageChinese  = text.LanguageChinese
+	LanguageKorean   = text.LanguageKorean
+)
+
+// DetectLanguage guesses the language of raw; see text.DetectLanguage
+func DetectLanguage(raw string) Language {
+	return text.DetectLanguage(raw)
+}
+
+// SplitSentences splits raw written in lang into trimmed sentences; see text.SplitSentences
+func SplitSentences(raw string, lang Language) []string {
+	return text.SplitSentences(raw, lang)
+}
+
+// sentenceSpans is SplitSentences returning byte ranges of raw instead of copies
+func sentenceSpans(raw string, lang Language) []span {
+	var spans []span
+	for _, sp := range text.SentenceSpans(raw, lang) {
+		spans = append(spans, span{sp.Start, sp.End})
+	}
+	return spans
+}
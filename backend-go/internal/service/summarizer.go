@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// DefaultSummaryConcurrency bounds the parallel LLM calls of one map or reduce round
+const DefaultSummaryConcurrency = 4
+
+// partSeparator joins the texts summarized together in one call
+const partSeparator = "\n\n"
+
+// SummarizerConfig holds the tunables of map-reduce summarization
+type SummarizerConfig struct {
+	// MaxTokensPerCall is the largest prompt sent to the LLM in one call; zero means no limit.
+	MaxTokensPerCall int
+	// PromptTokens is the size of the summary prompt the LLM wraps each text in, which
+	// counts against MaxTokensPerCall.
+	PromptTokens int
+	// Concurrency bounds the parallel calls of a round; zero selects DefaultSummaryConcurrency.
+	Concurrency int
+}
+
+// PartialSummary is an intermediate summary of a map-reduce run
+type PartialSummary = domain.PartialSummary
+
+// SummaryResult is the outcome of summarizing a document
+type SummaryResult struct {
+	Summary domain.Summary
+	// Partials holds the intermediate summaries level by level, in document order; it is empty
+	// when the document fit in one call.
+	Partials []PartialSummary
+}
+
+// Summarizer summarizes documents of any length with an LLM whose input is limited. A document
+// over the limit is summarized chunk group by chunk group in parallel (map), then the partial
+// summaries are summarized again, level by level, until they fit one final call (reduce).
+type Summarizer struct {
+	llm ports.LLM
+	tok ports.Tokenizer
+	cfg SummarizerConfig
+}
+
+// Validate checks that the configuration is usable
+func (c SummarizerConfig) Validate() error {
+	if c.MaxTokensPerCall < 0 || c.PromptTokens < 0 {
+		return fmt.Errorf("max tokens per call and prompt tokens must not be negative")
+	}
+	if c.MaxTokensPerCall > 0 && c.PromptTokens >= c.MaxTokensPerCall {
+		return fmt.Errorf("summary prompt of %d tokens leaves no room in the %d token call limit", c.PromptTokens, c.MaxTokensPerCall)
+	}
+	if c.Concurrency < 0 {
+		return fmt.Errorf("summary concurrency must not be negative")
+	}
+	return nil
+}
+
+// NewSummarizer creates a map-reduce summarizer counting tokens with tok
+func NewSummarizer(llm ports.LLM, tok ports.Tokenizer, cfg SummarizerConfig) *Summarizer {
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = DefaultSummaryConcurrency
+	}
+	return &Summarizer{llm: llm, tok: tok, cfg: cfg}
+}
+
+// Summarize summarizes raw, the text of document docID, split into chunks. Only paragraph chunks
+// are used, without the text they repeat from their predecessor; a chunk or partial summary too
+// long for one call is split into sentences that fit. It returns an error wrapping
+// domain.ErrTokenLimitExceeded when partial summaries stop getting shorter.
+func (s *Summarizer) Summarize(ctx context.Context, docID, raw string, chunks []domain.Chunk) (*SummaryResult, error) {
+	if err := s.cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if s.fits(raw) {
+		return s.final(ctx, docID, raw, nil)
+	}
+
+	var level []PartialSummary
+	for _, c := range chunks {
+		if c.Level != "" && c.Level != domain.ChunkLevelParagraph {
+			continue
+		}
+		text := c.Text
+		if c.Overlap != nil {
+			text = strings.TrimLeftFunc(text[c.Overlap.End:], unicode.IsSpace)
+		}
+		level = append(level, PartialSummary{ID: c.ID, Text: text})
+	}
+	if len(level) == 0 {
+		return nil, domain.NewErrTokenLimitExceeded(s.tok.CountTokens(raw), s.cfg.MaxTokensPerCall)
+	}
+
+	var partials []PartialSummary
+	for depth := 1; ; depth++ {
+		if s.fits(joinSummaries(level)) {
+			break
+		}
+		groups, err := s.group(level)
+		if err != nil {
+			return nil, err
+		}
+		if depth > 1 && len(groups) >= len(level) {
+			// Every partial summary needs a call of its own: reducing again would not shrink them
+			return nil, domain.NewErrTokenLimitExceeded(s.tok.CountTokens(joinSummaries(level)), s.cfg.MaxTokensPerCall)
+		}
+		next, err := s.summarizeGroups(ctx, docID, depth, groups)
+		if err != nil {
+			return nil, err
+		}
+		partials = append(partials, next...)
+		level = next
+	}
+	return s.final(ctx, docID, joinSummaries(level), linkParents(docID, partials))
+}
+
+// linkParents sets the ParentID of each partial summary: the partial summarizing it, or the
+// document summary when none does
+func linkParents(docID string, partials []PartialSummary) []PartialSummary {
+	parents := make(map[string]string)
+	for _, p := range partials {
+		for _, src := range p.SourceIDs {
+			parents[src] = p.ID
+		}
+	}
+	for i := range partials {
+		partials[i].ParentID = parents[partials[i].ID]
+		if partials[i].ParentID == "" {
+			partials[i].ParentID = summaryID(docID)
+		}
+	}
+	return partials
+}
+
+// final summarizes text into the document summary
+func (s *Summarizer) final(ctx context.Context, docID, text string, partials []PartialSummary) (*SummaryResult, error) {
+	summaryText, err := s.llm.GenerateSummary(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := BuildSummary(docID, summaryText)
+	if err != nil {
+		return nil, err
+	}
+	return &SummaryResult{Summary: summary, Partials: partials}, nil
+}
+
+// group packs consecutive parts into groups that fit one call. A part too long for a call of
+// its own is split into pieces that keep its ID.
+func (s *Summarizer) group(parts []PartialSummary) ([][]PartialSummary, error) {
+	pieces, err := s.split(parts)
+	if err != nil {
+		return nil, err
+	}
+	var groups [][]PartialSummary
+	var curr []PartialSummary
+	for _, p := range pieces {
+		if len(curr) > 0 && !s.fits(joinSummaries(append(curr, p))) {
+			groups = append(groups, curr)
+			curr = nil
+		}
+		curr = append(curr, p)
+	}
+	if len(curr) > 0 {
+		groups = append(groups, curr)
+	}
+	return groups, nil
+}
+
+// summarizeGroups summarizes each group in parallel into the partial summaries of one level
+func (s *Summarizer) summarizeGroups(ctx context.Context, docID string, level int, groups [][]PartialSummary) ([]PartialSummary, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make([]PartialSummary, len(groups))
+	sem := make(chan struct{}, s.cfg.Concurrency)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, group := range groups {
+		var sources []string
+		for _, p := range group {
+			// Pieces of a split part share its ID
+			if len(sources) == 0 || sources[len(sources)-1] != p.ID {
+				sources = append(sources, p.ID)
+			}
+		}
+		out[i] = PartialSummary{ID: fmt.Sprintf("%s_summary_%d_%d", docID, level, i), Level: level, SourceIDs: sources}
+
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				once.Do(func() { firstErr = ctx.Err() })
+				return
+			}
+			summary, err := s.llm.GenerateSummary(ctx, text)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to summarize %s: %w", out[i].ID, err)
+					cancel()
+				})
+				return
+			}
+			out[i].Text = summary
+		}(i, joinSummaries(group))
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+// split cuts every part too long for a call of its own into sentences that fit
+func (s *Summarizer) split(parts []PartialSummary) ([]PartialSummary, error) {
+	var out []PartialSummary
+	for _, p := range parts {
+		if s.fits(p.Text) {
+			out = append(out, p)
+			continue
+		}
+		texts, err := SegmentText(p.Text, s.budget(), s.tok)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split part %s: %w", p.ID, err)
+		}
+		for _, text := range texts {
+			if n := s.tok.CountTokens(text); !s.fitsCount(n) {
+				return nil, fmt.Errorf("part %s: %w", p.ID, domain.NewErrTokenLimitExceeded(n, s.budget()))
+			}
+			piece := p
+			piece.Text = text
+			out = append(out, piece)
+		}
+	}
+	return out, nil
+}
+
+// budget is the number of tokens of text one call leaves room for beside the prompt; zero means no limit
+func (s *Summarizer) budget() int {
+	if s.cfg.MaxTokensPerCall == 0 {
+		return 0
+	}
+	return s.cfg.MaxTokensPerCall - s.cfg.PromptTokens
+}
+
+func (s *Summarizer) fits(text string) bool {
+	return s.cfg.MaxTokensPerCall == 0 || s.fitsCount(s.tok.CountTokens(text))
+}
+
+func (s *Summarizer) fitsCount(n int) bool {
+	return s.cfg.MaxTokensPerCall == 0 || n <= s.budget()
+}
+
+func joinSummaries(parts []PartialSummary) string {
+	texts := make([]string, len(parts))
+	for i, p := range parts {
+		texts[i] = p.Text
+	}
+	return strings.Join(texts, partSeparator)
+}
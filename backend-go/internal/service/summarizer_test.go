@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+// summaryLLM summarizes a text into its first word, or echoes it back when echo is set
+type summaryLLM struct {
+	fakeLLM
+	echo bool
+	err  error
+
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (l *summaryLLM) GenerateSummary(ctx context.Context, text string) (string, error) {
+	l.mu.Lock()
+	l.inputs = append(l.inputs, text)
+	l.mu.Unlock()
+	if l.err != nil {
+		return "", l.err
+	}
+	if l.echo {
+		return text, nil
+	}
+	return strings.Fields(text)[0], nil
+}
+
+func summaryChunks(texts ...string) []domain.Chunk {
+	chunks := make([]domain.Chunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = domain.Chunk{ID: fmt.Sprintf("doc_%d", i), Text: text, Level: domain.ChunkLevelParagraph}
+	}
+	return chunks
+}
+
+func TestSummarizerSingleCall(t *testing.T) {
+	llm := &summaryLLM{}
+	s := NewSummarizer(llm, tokenizer.NewCharTokenizer(), SummarizerConfig{MaxTokensPerCall: 10})
+	result, err := s.Summarize(context.Background(), "doc", "alpha beta gamma", summaryChunks("alpha beta gamma"))
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Summary.Text != "alpha" || result.Summary.ID != "doc_summary" || len(result.Partials) != 0 || len(llm.inputs) != 1 {
+		t.Errorf("unexpected result %+v after %d calls", result, len(llm.inputs))
+	}
+}
+
+func TestSummarizerMapReduce(t *testing.T) {
+	llm := &summaryLLM{}
+	s := NewSummarizer(llm, tokenizer.NewCharTokenizer(), SummarizerConfig{MaxTokensPerCall: 4, Concurrency: 2})
+	chunks := summaryChunks("a1 a2 a3", "b1 b2 b3", "c1 c2 c3", "d1 d2 d3", "e1 e2 e3")
+	chunks[1].Text = "a3 b1 b2 b3"
+	chunks[1].Overlap = &domain.TextRange{Start: 0, End: 3}
+	raw := "a1 a2 a3 b1 b2 b3 c1 c2 c3 d1 d2 d3 e1 e2 e3"
+
+	result, err := s.Summarize(context.Background(), "doc", raw, chunks)
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	// Each chunk needs a call of its own (level 1), the five first words still exceed the
+	// limit and are reduced four and one (level 2), and the two results fit the final call.
+	levels := make(map[int][]PartialSummary)
+	for _, p := range result.Partials {
+		levels[p.Level] = append(levels[p.Level], p)
+	}
+	if len(levels[1]) != 5 || len(levels[2]) != 2 || len(result.Partials) != 7 {
+		t.Fatalf("unexpected partial summaries %+v", result.Partials)
+	}
+	if got := levels[1][1]; got.Text != "b1" || got.SourceIDs[0] != "doc_1" || got.ID != "doc_summary_1_1" {
+		t.Errorf("overlap prefix should be dropped before summarizing, got %+v", got)
+	}
+	if got := levels[2][0]; strings.Join(got.SourceIDs, ",") != "doc_summary_1_0,doc_summary_1_1,doc_summary_1_2,doc_summary_1_3" {
+		t.Errorf("unexpected level 2 sources %v", got.SourceIDs)
+	}
+	if levels[1][4].ParentID != "doc_summary_2_1" || levels[2][0].ParentID != "doc_summary" {
+		t.Errorf("unexpected parents %q and %q", levels[1][4].ParentID, levels[2][0].ParentID)
+	}
+	if result.Summary.Text != "a1" {
+		t.Errorf("final summary = %q", result.Summary.Text)
+	}
+}
+
+func TestSummarizerTokenLimit(t *testing.T) {
+	tok := tokenizer.NewCharTokenizer()
+	t.Run("summaries do not shrink", func(t *testing.T) {
+		s := NewSummarizer(&summaryLLM{echo: true}, tok, SummarizerConfig{MaxTokensPerCall: 2})
+		_, err := s.Summarize(context.Background(), "doc", "a b c d", summaryChunks("a b", "c d"))
+		if !errors.Is(err, domain.ErrTokenLimitExceeded) {
+			t.Errorf("expected ErrTokenLimitExceeded, got %v", err)
+		}
+	})
+}
+
+func TestSummarizerSplitsOversizedChunk(t *testing.T) {
+	llm := &summaryLLM{}
+	tok := tokenizer.NewCharTokenizer()
+	s := NewSummarizer(llm, tok, SummarizerConfig{MaxTokensPerCall: 2})
+	result, err := s.Summarize(context.Background(), "doc", "a b c d", summaryChunks("a b c", "d"))
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	for _, input := range llm.inputs {
+		if n := tok.CountTokens(input); n > 2 {
+			t.Errorf("call of %d tokens exceeds the limit: %q", n, input)
+		}
+	}
+	// Both pieces of the first chunk are summarized, and each chunk is listed once per partial
+	var sources []string
+	for _, p := range result.Partials {
+		if p.Level == 1 {
+			sources = append(sources, strings.Join(p.SourceIDs, ","))
+		}
+	}
+	if got := strings.Join(sources, " "); got != "doc_0 doc_0,doc_1" {
+		t.Errorf("unexpected level 1 sources %q", got)
+	}
+}
+
+func TestSummarizerCountsPromptTokens(t *testing.T) {
+	llm := &summaryLLM{}
+	tok := tokenizer.NewCharTokenizer()
+	s := NewSummarizer(llm, tok, SummarizerConfig{MaxTokensPerCall: 10, PromptTokens: 8})
+	if _, err := s.Summarize(context.Background(), "doc", "alpha beta gamma", summaryChunks("alpha beta gamma")); err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if len(llm.inputs) < 2 {
+		t.Fatalf("text over the budget left by the prompt should be split, got calls %q", llm.inputs)
+	}
+	for _, input := range llm.inputs {
+		if n := tok.CountTokens(input); n > 2 {
+			t.Errorf("call of %d tokens leaves no room for the prompt: %q", n, input)
+		}
+	}
+}
+
+func TestSummarizerConfigRejectsPromptOverLimit(t *testing.T) {
+	cfg := SummarizerConfig{MaxTokensPerCall: 8, PromptTokens: 8}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for a prompt filling the whole call")
+	}
+}
+
+func TestSummarizerPropagatesErrors(t *testing.T) {
+	llm := &summaryLLM{err: errors.New("quota")}
+	s := NewSummarizer(llm, tokenizer.NewCharTokenizer(), SummarizerConfig{MaxTokensPerCall: 2})
+	_, err := s.Summarize(context.Background(), "doc", "a b c d", summaryChunks("a b", "c d"))
+	if err == nil || !strings.Contains(err.Error(), "quota") {
+		t.Errorf("expected the LLM error, got %v", err)
+	}
+}
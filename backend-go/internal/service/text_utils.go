@@ -2,43 +2,185 @@ package service
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
 )
 
-// TODO: Currently uses whitespace splitting; to switch to LLM tokenizer, update here.
-func CountTokens(text string) int {
-	return len(strings.Fields(text))
+// Segment is a piece of a document that becomes one Chunk.
+// Text is always the exact source text covered by Span.
+type Segment struct {
+	Text string
+	// Span locates Text in the original document.
+	Span domain.SourceSpan
+	// HeadingPath is the chain of markdown headings enclosing the segment, e.g. "Intro > Setup".
+	HeadingPath string
+	// Overlap is the number of leading bytes of Text repeated from the previous segment; the
+	// whitespace that separated the two segments follows them in Text.
+	Overlap int
+	// Level places the segment in a chunk tree; empty means a flat paragraph.
+	Level domain.ChunkLevel
+	// Parent is the index of the enclosing segment in the same slice, or -1 at the top level.
+	// It is only read for segments with a Level.
+	Parent int
+}
+
+// span is a half-open byte range of a source text
+type span struct {
+	start, end int
+}
+
+// SegmentText splits raw text into chunks by grouping sentences until maxTokens, as counted by tok, is reached.
+// The language is detected from raw. A sentence longer than maxTokens is split at word boundaries
+// (character boundaries for CJK). Chunks are verbatim slices of raw, original whitespace included.
+func SegmentText(raw string, maxTokens int, tok ports.Tokenizer) ([]string, error) {
+	segments, err := SegmentProse(raw, maxTokens, tok)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	return texts, nil
 }
 
-// SegmentText splits raw text into chunks by grouping sentences until maxTokens is reached.
-func SegmentText(raw string, maxTokens int) ([]string, error) {
+// SegmentProse is SegmentText returning segments with their source spans
+func SegmentProse(raw string, maxTokens int, tok ports.Tokenizer) ([]Segment, error) {
 	if maxTokens <= 0 {
 		return nil, fmt.Errorf("maxTokens must be > 0")
 	}
-	// Split into sentences (RE2-compatible): match sequences ending with punctuation
-	re := regexp.MustCompile(`[^.!?]+[.!?]`)
-	sentences := re.FindAllString(raw, -1)
-	var chunks []string
-	var curr []string
+	lang := DetectLanguage(raw)
+	lines := newLineIndex(raw)
+	var segments []Segment
+	for _, sp := range proseSpans(raw, span{0, len(raw)}, lang, maxTokens, tok) {
+		segments = append(segments, Segment{Text: raw[sp.start:sp.end], Span: lines.locate(sp)})
+	}
+	return segments, nil
+}
+
+// proseSpans groups the sentences of raw[within] into spans of at most maxTokens
+func proseSpans(raw string, within span, lang Language, maxTokens int, tok ports.Tokenizer) []span {
+	var units []span
+	for _, sp := range sentenceSpans(raw[within.start:within.end], lang) {
+		sp = span{sp.start + within.start, sp.end + within.start}
+		if tok.CountTokens(raw[sp.start:sp.end]) > maxTokens {
+			units = append(units, wordSpans(raw, sp, maxTokens, tok)...)
+			continue
+		}
+		units = append(units, sp)
+	}
+	return packSpans(raw, units, maxTokens, tok)
+}
+
+// wordSpans breaks raw[within], which is too long for one chunk, at word boundaries,
+// falling back to single characters for words (or unspaced CJK runs) over maxTokens
+func wordSpans(raw string, within span, maxTokens int, tok ports.Tokenizer) []span {
+	var pieces []span
+	for _, word := range fieldSpans(raw, within) {
+		if tok.CountTokens(raw[word.start:word.end]) <= maxTokens {
+			pieces = append(pieces, word)
+			continue
+		}
+		var chars []span
+		for i := word.start; i < word.end; {
+			_, size := utf8.DecodeRuneInString(raw[i:])
+			chars = append(chars, span{i, i + size})
+			i += size
+		}
+		pieces = append(pieces, packSpans(raw, chars, maxTokens, tok)...)
+	}
+	return packSpans(raw, pieces, maxTokens, tok)
+}
+
+// packSpans merges consecutive units while their total token count stays within maxTokens.
+// A merged span covers its units and the source text between them.
+func packSpans(raw string, units []span, maxTokens int, tok ports.Tokenizer) []span {
+	var packed []span
+	var curr span
 	currCount := 0
-	for _, sent := range sentences {
-		tokCount := CountTokens(sent)
+	for _, unit := range units {
+		tokCount := tok.CountTokens(raw[unit.start:unit.end])
 		if tokCount == 0 {
 			continue
 		}
-		// if adding this sentence exceeds maxTokens, start new chunk
+		// if adding this unit exceeds maxTokens, start new chunk
 		if currCount > 0 && currCount+tokCount > maxTokens {
-			chunks = append(chunks, strings.Join(curr, " "))
-			curr = []string{sent}
-			currCount = tokCount
+			packed = append(packed, curr)
+			curr, currCount = unit, tokCount
 		} else {
-			curr = append(curr, sent)
+			if currCount == 0 {
+				curr.start = unit.start
+			}
+			curr.end = unit.end
 			currCount += tokCount
 		}
 	}
-	if len(curr) > 0 {
-		chunks = append(chunks, strings.Join(curr, " "))
+	if currCount > 0 {
+		packed = append(packed, curr)
+	}
+	return packed
+}
+
+// fieldSpans is strings.Fields over raw[within], returning byte ranges
+func fieldSpans(raw string, within span) []span {
+	var fields []span
+	start := -1
+	for i, r := range raw[within.start:within.end] {
+		i += within.start
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, span{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, span{start, within.end})
+	}
+	return fields
+}
+
+// lineIndex converts byte offsets of a text into 1-based line numbers
+type lineIndex struct {
+	newlines []int // byte offsets of '\n'
+}
+
+func newLineIndex(raw string) lineIndex {
+	var idx lineIndex
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\n' {
+			idx.newlines = append(idx.newlines, i)
+		}
+	}
+	return idx
+}
+
+// line returns the 1-based line holding byte offset pos
+func (l lineIndex) line(pos int) int {
+	return sort.SearchInts(l.newlines, pos) + 1
+}
+
+// locate returns the source span of the bytes in sp; EndLine is the line of its last byte
+func (l lineIndex) locate(sp span) domain.SourceSpan {
+	last := sp.end - 1
+	if last < sp.start {
+		last = sp.start
+	}
+	return domain.SourceSpan{
+		StartByte: sp.start,
+		EndByte:   sp.end,
+		StartLine: l.line(sp.start),
+		EndLine:   l.line(last),
 	}
-	return chunks, nil
+}
+
+// spanOf returns the byte range of a segment
+func spanOf(seg Segment) span {
+	return span{seg.Span.StartByte, seg.Span.EndByte}
 }
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ran/demo/backend-go/internal/infra/tokenizer"
+)
+
+func TestSegmentTextJapanese(t *testing.T) {
+	// Each full sentence costs 6 tokens: 5 characters plus the terminator
+	chunks, err := SegmentText("一つ目の文。二つ目の文。三つ目", 12, tokenizer.NewCharTokenizer())
+	if err != nil {
+		t.Fatalf("SegmentText failed: %v", err)
+	}
+	want := []string{"一つ目の文。二つ目の文。", "三つ目"}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("SegmentText() = %q, want %q", chunks, want)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ran/demo/backend-go/internal/domain"
+	"github.com/ran/demo/backend-go/internal/domain/ports"
+)
+
+// sniffLen is the number of leading bytes inspected to detect a file's content type
+const sniffLen = 512
+
+// errNotText stops reading a file once it is known not to be plain text
+var errNotText = errors.New("not plain text")
+
+// allowedExtensions lists the document formats accepted for upload
+var allowedExtensions = map[string]bool{
+	".txt": true,
+	".md":  true,
+}
+
+// FileUploader implements ports.DocumentUploader by copying local files into a storage
+// directory and registering them as documents in StatusUploaded.
+type FileUploader struct {
+	storageDir  string
+	maxFileSize int64
+	repo        ports.DocumentRepository
+	now         func() time.Time
+}
+
+// NewFileUploader creates a new uploader storing files under storageDir
+func NewFileUploader(storageDir string, maxFileSize int64, repo ports.DocumentRepository) (*FileUploader, error) {
+	if storageDir == "" {
+		return nil, fmt.Errorf("upload storage directory is required")
+	}
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %q: %w", storageDir, err)
+	}
+	return &FileUploader{
+		storageDir:  storageDir,
+		maxFileSize: maxFileSize,
+		repo:        repo,
+		now:         time.Now,
+	}, nil
+}
+
+// Upload validates every file before storing any of them, so a request is accepted or rejected as a whole.
+func (u *FileUploader) Upload(ctx context.Context, filePaths []string) ([]domain.Document, error) {
+	if len(filePaths) == 0 {
+		return nil, domain.ErrNoFiles
+	}
+	for _, path := range filePaths {
+		if err := u.validate(path); err != nil {
+			return nil, err
+		}
+	}
+
+	docs := make([]domain.Document, 0, len(filePaths))
+	for _, path := range filePaths {
+		if err := ctx.Err(); err != nil {
+			u.remove(docs)
+			return nil, err
+		}
+		id, err := newDocumentID()
+		if err != nil {
+			u.remove(docs)
+			return nil, err
+		}
+		doc := domain.Document{
+			ID:        id,
+			Filename:  filepath.Base(path),
+			Status:    domain.StatusUploaded,
+			CreatedAt: u.now(),
+		}
+		if err := copyFile(path, u.contentPath(doc)); err != nil {
+			u.remove(append(docs, doc))
+			return nil, fmt.Errorf("failed to store %s: %w", doc.Filename, err)
+		}
+		docs = append(docs, doc)
+	}
+	// Documents are registered only once every file is stored, so a failed request leaves none behind.
+	for i, doc := range docs {
+		if err := u.repo.Save(ctx, doc); err != nil {
+			u.unregister(docs[:i])
+			u.remove(docs)
+			return nil, fmt.Errorf("failed to save document %s: %w", doc.ID, err)
+		}
+	}
+	return docs, nil
+}
+
+// remove deletes the stored files of docs
+func (u *FileUploader) remove(docs []domain.Document) {
+	for _, doc := range docs {
+		os.Remove(u.contentPath(doc))
+	}
+}
+
+// unregister deletes docs from the repository. It runs while a request is already failing, so it
+// does not stop at a cancelled context or report errors of its own.
+func (u *FileUploader) unregister(docs []domain.Document) {
+	ctx := context.Background()
+	for _, doc := range docs {
+		u.repo.Delete(ctx, doc.ID)
+	}
+}
+
+// ReadText returns the stored content of an uploaded document
+func (u *FileUploader) ReadText(doc domain.Document) (string, error) {
+	data, err := os.ReadFile(u.contentPath(doc))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", domain.ErrDocumentNotFound
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (u *FileUploader) contentPath(doc domain.Document) string {
+	return filepath.Join(u.storageDir, doc.ID+strings.ToLower(filepath.Ext(doc.Filename)))
+}
+
+// validate checks the file name, extension and size of a file, and that it holds UTF-8 text
+// without NUL bytes whose sniffed content type is textual
+func (u *FileUploader) validate(path string) error {
+	name := filepath.Base(path)
+	if path == "" || name == "." || name == string(filepath.Separator) {
+		return domain.ErrEmptyFilename
+	}
+	if !allowedExtensions[strings.ToLower(filepath.Ext(name))] {
+		return fmt.Errorf("%w: %s", domain.ErrUnsupportedFileType, name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrEmptyText, name)
+	}
+	if u.maxFileSize > 0 && info.Size() > u.maxFileSize {
+		return fmt.Errorf("%w: %s is %d bytes, limit is %d", domain.ErrFileTooLarge, name, info.Size(), u.maxFileSize)
+	}
+
+	var text textValidator
+	if _, err := io.Copy(&text, f); err != nil && !errors.Is(err, errNotText) {
+		return err
+	}
+	// Markdown may open with HTML and sniff as text/html, so any text type is accepted.
+	if !text.valid() || !strings.HasPrefix(http.DetectContentType(text.head), "text/") {
+		return fmt.Errorf("%w: %s is not plain text", domain.ErrUnsupportedFileType, name)
+	}
+	return nil
+}
+
+// textValidator checks, as a file is streamed through it, that the file is UTF-8 without NUL
+// bytes. It keeps only the leading bytes for content sniffing and a rune cut between writes.
+type textValidator struct {
+	head    []byte
+	tail    []byte
+	invalid bool
+}
+
+func (v *textValidator) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(v.head) < sniffLen {
+		v.head = append(v.head, p[:min(len(p), sniffLen-len(v.head))]...)
+	}
+	if len(v.tail) > 0 {
+		for len(p) > 0 && !utf8.FullRune(v.tail) {
+			v.tail = append(v.tail, p[0])
+			p = p[1:]
+		}
+		if !utf8.FullRune(v.tail) {
+			return n, nil
+		}
+		v.check(v.tail)
+		v.tail = v.tail[:0]
+	}
+	cut := len(p)
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	v.check(p[:cut])
+	v.tail = append(v.tail, p[cut:]...)
+	if v.invalid {
+		return n, errNotText
+	}
+	return n, nil
+}
+
+func (v *textValidator) check(b []byte) {
+	if !utf8.Valid(b) || bytes.IndexByte(b, 0) >= 0 {
+		v.invalid = true
+	}
+}
+
+// valid reports whether everything written was UTF-8 text without NUL bytes
+func (v *textValidator) valid() bool {
+	return !v.invalid && len(v.tail) == 0
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// newDocumentID returns a random RFC 4122 version 4 UUID
+func newDocumentID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate document id: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
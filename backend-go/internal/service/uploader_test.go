@@ -0,0 +1,32 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTextValidatorAcrossWrites(t *testing.T) {
+	text := []byte("naïve café — 日本語のテキスト")
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"utf-8", text, true},
+		{"truncated rune", text[:len(text)-1], false},
+		{"invalid byte", append(bytes.Clone(text), 0xff, 'a'), false},
+		{"NUL byte", append(bytes.Clone(text), 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Writing one byte at a time cuts every multi-byte rune between writes.
+			var v textValidator
+			for i := range tt.data {
+				v.Write(tt.data[i : i+1])
+			}
+			if got := v.valid(); got != tt.want {
+				t.Errorf("valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Package text holds language-aware text utilities shared by the services and the offline models.
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language is the dominant script family of a text, as far as sentence splitting cares
+type Language string
+
+const (
+	LanguageEnglish  Language = "en"
+	LanguageJapanese Language = "ja"
+	LanguageChinese  Language = "zh"
+	LanguageKorean   Language = "ko"
+)
+
+// IsCJK reports whether sentences of l are written without separating spaces
+func (l Language) IsCJK() bool {
+	return l == LanguageJapanese || l == LanguageChinese
+}
+
+// abbreviations are lower-cased words that end with a period without ending the sentence
+var abbreviations = map[string]bool{
+	"e.g": true, "i.e": true, "cf": true, "vs": true, "al": true,
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "mt": true, "fig": true, "eq": true, "vol": true, "approx": true,
+	"inc": true, "ltd": true, "co": true, "corp": true, "jan": true, "feb": true, "mar": true,
+	"apr": true, "jun": true, "jul": true, "aug": true, "sep": true, "sept": true, "oct": true,
+	"nov": true, "dec": true,
+}
+
+// closers may follow a terminator and still belong to the sentence it ends
+const closers = "\"')]}”’」』）］】〕》〉"
+
+// cjkOpeners and cjkClosers delimit quotations inside which CJK terminators do not split
+const (
+	cjkOpeners = "「『（【〔《〈"
+	cjkClosers = "」』）】〕》〉"
+)
+
+// DetectLanguage guesses the language of text from its scripts: any kana means Japanese,
+// Hangul means Korean, Han alone means Chinese, and anything else is treated as English.
+func DetectLanguage(text string) Language {
+	var han, kana, hangul, letters int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	cjk := han + kana + hangul
+	if cjk == 0 || cjk < letters/4 {
+		// A few CJK names in Latin text do not make it a CJK document
+		return LanguageEnglish
+	}
+	switch {
+	case kana > 0:
+		return LanguageJapanese
+	case hangul >= han:
+		return LanguageKorean
+	default:
+		return LanguageChinese
+	}
+}
+
+// SplitSentences splits text written in lang into trimmed sentences. It recognises ASCII and
+// full-width terminators, keeps closing quotes and brackets with their sentence, does not split
+// inside CJK quotations, on decimals or after common abbreviations and initials, treats blank
+// lines as boundaries, and returns trailing text without a terminator as a final sentence.
+func SplitSentences(text string, lang Language) []string {
+	spans := SentenceSpans(text, lang)
+	sentences := make([]string, len(spans))
+	for i, sp := range spans {
+		sentences[i] = text[sp.Start:sp.End]
+	}
+	return sentences
+}
+
+// Span is a half-open byte range [Start, End) of a text
+type Span struct {
+	Start, End int
+}
+
+// SentenceSpans is SplitSentences returning byte ranges of text instead of copies
+func SentenceSpans(text string, lang Language) []Span {
+	var (
+		runes   []rune
+		offsets []int // byte offset of each rune, plus len(text)
+	)
+	for i, r := range text {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	var spans []Span
+	start, depth := 0, 0
+	emit := func(end int) {
+		s, e := start, end
+		for s < e && unicode.IsSpace(runes[s]) {
+			s++
+		}
+		for e > s && unicode.IsSpace(runes[e-1]) {
+			e--
+		}
+		if s < e {
+			spans = append(spans, Span{offsets[s], offsets[e]})
+		}
+		start = end
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case strings.ContainsRune(cjkOpeners, r):
+			depth++
+			continue
+		case strings.ContainsRune(cjkClosers, r) && depth > 0:
+			depth--
+			continue
+		case r == '\n' && isBlankLineAhead(runes, i):
+			depth = 0
+			emit(i)
+			continue
+		}
+		if !isTerminator(r, lang) || depth > 0 {
+			continue
+		}
+		if r == '.' && !endsSentenceAtPeriod(runes, i) {
+			continue
+		}
+		// Absorb repeated terminators ("?!", "...") and closing quotes/brackets
+		end := i + 1
+		for end < len(runes) && (isTerminator(runes[end], lang) || strings.ContainsRune(closers, runes[end])) {
+			end++
+		}
+		// ASCII terminators only end a sentence before whitespace, so "example.com" and "3.14" survive
+		if isASCIITerminator(r) && end < len(runes) && !unicode.IsSpace(runes[end]) && !isCJKRune(runes[end]) {
+			continue
+		}
+		emit(end)
+		i = end - 1
+	}
+	emit(len(runes))
+	return spans
+}
+
+// isTerminator reports whether r ends a sentence. The full-width period "．" is only a
+// terminator in CJK text, where some (mostly academic) writing uses it in place of "。".
+func isTerminator(r rune, lang Language) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？', '｡', '‼', '⁇', '⁈', '⁉':
+		return true
+	case '．':
+		return lang.IsCJK()
+	}
+	return false
+}
+
+func isASCIITerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?'
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isBlankLineAhead reports whether the newline at i starts a blank line (paragraph break)
+func isBlankLineAhead(runes []rune, i int) bool {
+	for j := i + 1; j < len(runes); j++ {
+		switch runes[j] {
+		case '\n':
+			return true
+		case ' ', '\t', '\r', '　':
+			continue
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// endsSentenceAtPeriod rejects periods inside decimals, after abbreviations and after initials
+func endsSentenceAtPeriod(runes []rune, i int) bool {
+	if i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+		return false
+	}
+	// The word before the period, e.g. "Dr" or "e.g"
+	j := i
+	for j > 0 && (unicode.IsLetter(runes[j-1]) || runes[j-1] == '.') {
+		j--
+	}
+	word := string(runes[j:i])
+	if word == "" || isCJKRune(runes[i-1]) {
+		return true
+	}
+	if len([]rune(word)) == 1 && unicode.IsUpper(runes[j]) {
+		// An initial such as "J. Smith"; a lone capital at the end of text still ends it
+		return !hasFollowingWord(runes, i)
+	}
+	if abbreviations[strings.ToLower(word)] {
+		return false
+	}
+	// Dotted acronyms like "U.S." or "p.m." are abbreviations too, but "example.com." is not
+	if strings.Contains(word, ".") {
+		for _, part := range strings.Split(word, ".") {
+			if len([]rune(part)) > 2 {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func hasFollowingWord(runes []rune, i int) bool {
+	for j := i + 1; j < len(runes); j++ {
+		if runes[j] == '\n' {
+			return false
+		}
+		if !unicode.IsSpace(runes[j]) {
+			return unicode.IsLetter(runes[j])
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		lang Language
+		want []string
+	}{
+		{
+			name: "english with trailing fragment",
+			text: "One two three. Four five six! Seven eight",
+			lang: LanguageEnglish,
+			want: []string{"One two three.", "Four five six!", "Seven eight"},
+		},
+		{
+			name: "abbreviations decimals and initials",
+			text: "Dr. Smith paid 3.5 dollars, e.g. for tea at example.com. J. R. R. Tolkien agreed. The U.S. team won.",
+			lang: LanguageEnglish,
+			want: []string{"Dr. Smith paid 3.5 dollars, e.g. for tea at example.com.", "J. R. R. Tolkien agreed.", "The U.S. team won."},
+		},
+		{
+			name: "quotes and repeated terminators",
+			text: `He said "Stop." Then he left?! Really...`,
+			lang: LanguageEnglish,
+			want: []string{`He said "Stop."`, "Then he left?!", "Really..."},
+		},
+		{
+			name: "japanese full-width punctuation",
+			text: "本プロジェクトでは情報を構造化します。本当ですか？はい！最後の文",
+			lang: LanguageJapanese,
+			want: []string{"本プロジェクトでは情報を構造化します。", "本当ですか？", "はい！", "最後の文"},
+		},
+		{
+			name: "japanese quotation is not split",
+			text: "彼は「行こう。今すぐに！」と言った。「はい。」",
+			lang: LanguageJapanese,
+			want: []string{"彼は「行こう。今すぐに！」と言った。", "「はい。」"},
+		},
+		{
+			name: "blank line ends a heading without punctuation",
+			text: "# 概要\n\n本文です。",
+			lang: LanguageJapanese,
+			want: []string{"# 概要", "本文です。"},
+		},
+		{
+			name: "full-width period in japanese",
+			text: "図１を示す．次に進む．",
+			lang: LanguageJapanese,
+			want: []string{"図１を示す．", "次に進む．"},
+		},
+		{
+			name: "chinese",
+			text: "我们开始吧。你好吗？",
+			lang: LanguageChinese,
+			want: []string{"我们开始吧。", "你好吗？"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSentences(tt.text, tt.lang); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]Language{
+		"Plain English text.": LanguageEnglish,
+		"ベクトル化されたテキストデータを配置します。": LanguageJapanese,
+		"我们开始吧。":                            LanguageChinese,
+		"안녕하세요.":                            LanguageKorean,
+		"A long English sentence about 東京.": LanguageEnglish,
+	}
+	for text, want := range tests {
+		if got := DetectLanguage(text); got != want {
+			t.Errorf("DetectLanguage(%q) = %s, want %s", text, got, want)
+		}
+	}
+}